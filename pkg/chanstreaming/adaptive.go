@@ -0,0 +1,271 @@
+package chanstreaming
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type LimitAlgorithm int
+
+const (
+	AIMD     LimitAlgorithm = iota // grows the limit by one on each fast success, multiplies it by BackoffRatio on each failure or slow call
+	Gradient                       // moves the limit along the ratio of the lowest observed latency to the current one, backing off on failures
+)
+
+// AdaptiveLimiterConfig defines the bounds of an AdaptiveLimiter and how it reacts to the observed calls.
+type AdaptiveLimiterConfig struct {
+	Algorithm LimitAlgorithm
+	// InitialLimit is the limit before any call is observed, zero means MinLimit
+	InitialLimit int
+	// MinLimit is the lowest limit, values below 1 mean 1
+	MinLimit int
+	// MaxLimit is the highest limit, zero or less means 1000
+	MaxLimit int
+	// BackoffRatio multiplies the limit on a failure, defaults to 0.9
+	BackoffRatio float64
+	// LatencyThreshold makes the AIMD algorithm treat slower calls as failures, zero disables the check
+	LatencyThreshold time.Duration
+	// Tolerance is how many times the lowest observed latency the Gradient algorithm accepts without shrinking the limit, defaults to 1.5
+	Tolerance float64
+}
+
+// gradientSmoothing is the weight of a new estimate in the limit of the Gradient algorithm
+const gradientSmoothing = 0.2
+
+// AdaptiveLimiter limits the number of calls in flight, adjusting the limit to the observed latencies and failures
+// so that a stage backs off from a degraded dependency and speeds up against a fast one.
+// A single AdaptiveLimiter may be shared by several stages calling the same dependency.
+type AdaptiveLimiter struct {
+	mu       sync.Mutex
+	config   AdaptiveLimiterConfig
+	limit    float64
+	inFlight int
+	// minLatency is the lowest latency observed by the Gradient algorithm
+	minLatency time.Duration
+	// changed is closed and replaced whenever a call ends or the limit is recorded, waking the callers waiting for a slot
+	changed chan struct{}
+}
+
+// NewAdaptiveLimiter creates an AdaptiveLimiter starting at InitialLimit.
+func NewAdaptiveLimiter(config AdaptiveLimiterConfig) *AdaptiveLimiter {
+	config.MinLimit = max(config.MinLimit, 1)
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = 1000
+	}
+	config.MaxLimit = max(config.MaxLimit, config.MinLimit)
+	if config.InitialLimit <= 0 {
+		config.InitialLimit = config.MinLimit
+	}
+	if config.BackoffRatio <= 0 || config.BackoffRatio >= 1 {
+		config.BackoffRatio = 0.9
+	}
+	if config.Tolerance < 1 {
+		config.Tolerance = 1.5
+	}
+	l := &AdaptiveLimiter{config: config, changed: make(chan struct{})}
+	l.limit = l.clamp(float64(config.InitialLimit))
+	return l
+}
+
+// Limit returns the current number of calls allowed in flight.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of calls currently in flight.
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// Record adjusts the limit to the latency and the outcome of a call, for callers running their calls outside of MapAdaptive.
+func (l *AdaptiveLimiter) Record(latency time.Duration, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.record(latency, failed)
+	l.notify()
+}
+
+func (l *AdaptiveLimiter) record(latency time.Duration, failed bool) {
+	if failed {
+		l.limit = l.clamp(l.limit * l.config.BackoffRatio)
+		return
+	}
+	switch l.config.Algorithm {
+	case Gradient:
+		if l.minLatency == 0 || latency < l.minLatency {
+			l.minLatency = latency
+		}
+		gradient := 1.0
+		if latency > 0 {
+			gradient = max(0.5, min(1, l.config.Tolerance*float64(l.minLatency)/float64(latency)))
+		}
+		// the square root of the limit is the queue allowed on top of the estimate, letting the limit probe upwards
+		estimate := l.limit*gradient + math.Sqrt(l.limit)
+		l.limit = l.clamp(l.limit*(1-gradientSmoothing) + estimate*gradientSmoothing)
+	default:
+		if l.config.LatencyThreshold > 0 && latency > l.config.LatencyThreshold {
+			l.limit = l.clamp(l.limit * l.config.BackoffRatio)
+			return
+		}
+		l.limit = l.clamp(l.limit + 1)
+	}
+}
+
+func (l *AdaptiveLimiter) clamp(limit float64) float64 {
+	return max(float64(l.config.MinLimit), min(float64(l.config.MaxLimit), limit))
+}
+
+// acquire waits for a slot under the current limit, reporting false on cancellation of the context.Context.
+func (l *AdaptiveLimiter) acquire(ctx context.Context) bool {
+	for {
+		l.mu.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.mu.Unlock()
+			return true
+		}
+		changed := l.changed
+		l.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// release frees the slot taken by acquire, recording the latency and the outcome of the call.
+func (l *AdaptiveLimiter) release(latency time.Duration, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.record(latency, failed)
+	l.vacate()
+}
+
+// abandon frees the slot taken by acquire for a call that never ran.
+func (l *AdaptiveLimiter) abandon() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.vacate()
+}
+
+func (l *AdaptiveLimiter) vacate() {
+	l.inFlight--
+	l.notify()
+}
+
+func (l *AdaptiveLimiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// callAdaptive invokes `fn`, returning its Result along with the latency of the call.
+func callAdaptive[R any](clock Clock, fn func() (R, error)) (Result[R], time.Duration) {
+	start := clock.Now()
+	data, err := tryCall(fn)
+	latency := clock.Now().Sub(start)
+	if err != nil {
+		return NewError[R](err), latency
+	}
+	return NewResult(data), latency
+}
+
+// MapAdaptive applies a fallible transformation function to each element in parallel, preserving order,
+// running as many calls at once as the AdaptiveLimiter allows. Errors and panics of `fn` count as failures.
+func MapAdaptive[T any, R any](fn func(T) (R, error), limiter *AdaptiveLimiter) func(in <-chan T) <-chan Result[R] {
+	return MapAdaptiveCtx[T, R](context.Background(), fn, limiter)
+}
+
+// MapAdaptiveCtx works like MapAdaptive, closing the resulting channel on cancellation of the context.Context.
+// Calls of `fn` that are already running are not interrupted, their results are discarded.
+func MapAdaptiveCtx[T any, R any](ctx context.Context, fn func(T) (R, error), limiter *AdaptiveLimiter) func(in <-chan T) <-chan Result[R] {
+	return func(in <-chan T) <-chan Result[R] {
+		// the tasks are queued in order of arrival, the limiter bounds how many of them run at once
+		tasksChannel := make(chan (chan Result[R]), limiter.config.MaxLimit)
+		clock := ClockFromContext(ctx)
+
+		go func() {
+			defer close(tasksChannel)
+			for {
+				item, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if !limiter.acquire(ctx) {
+					return
+				}
+				resultChan := make(chan Result[R], 1)
+				if !sendCtx(ctx, tasksChannel, resultChan) {
+					limiter.abandon()
+					return
+				}
+				go func() {
+					result, latency := callAdaptive(clock, func() (R, error) { return fn(item) })
+					limiter.release(latency, result.Error != nil)
+					resultChan <- result
+					close(resultChan)
+				}()
+			}
+		}()
+
+		outChannel := make(chan Result[R], 1)
+		go func() {
+			defer close(outChannel)
+			for resultChan := range tasksChannel {
+				result, ok := recvCtx(ctx, resultChan)
+				if !ok {
+					return
+				}
+				if !sendCtx(ctx, outChannel, result) {
+					return
+				}
+			}
+		}()
+
+		return outChannel
+	}
+}
+
+// MapUnorderedAdaptive works like MapAdaptive without preserving order.
+func MapUnorderedAdaptive[T any, R any](fn func(T) (R, error), limiter *AdaptiveLimiter) func(in <-chan T) <-chan Result[R] {
+	return MapUnorderedAdaptiveCtx[T, R](context.Background(), fn, limiter)
+}
+
+// MapUnorderedAdaptiveCtx works like MapUnorderedAdaptive, closing the resulting channel on cancellation of the context.Context.
+func MapUnorderedAdaptiveCtx[T any, R any](ctx context.Context, fn func(T) (R, error), limiter *AdaptiveLimiter) func(in <-chan T) <-chan Result[R] {
+	return func(in <-chan T) <-chan Result[R] {
+		out := make(chan Result[R], 1)
+		clock := ClockFromContext(ctx)
+		go func() {
+			var wg sync.WaitGroup
+			defer func() {
+				wg.Wait()
+				close(out)
+			}()
+			for {
+				item, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if !limiter.acquire(ctx) {
+					return
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					result, latency := callAdaptive(clock, func() (R, error) { return fn(item) })
+					// the slot is held until the result is taken, so a slow consumer slows the calls down too
+					sendCtx(ctx, out, result)
+					limiter.release(latency, result.Error != nil)
+				}()
+			}
+		}()
+		return out
+	}
+}
@@ -0,0 +1,119 @@
+package chanstreaming
+
+import (
+	"math"
+	"sort"
+)
+
+// Aggregator incrementally folds the elements of a window, so that sliding windows don't recopy their buffer on each element.
+// Remove is always called with the oldest element still in the Aggregator.
+type Aggregator[T any, R any] interface {
+	Add(x T)
+	Remove(x T)
+	Result() R
+}
+
+// collectAggregator is the Aggregator collecting the window elements as is
+type collectAggregator[T any] struct {
+	elements []T
+}
+
+func newCollectAggregator[T any]() Aggregator[T, []T] {
+	return &collectAggregator[T]{}
+}
+
+func (a *collectAggregator[T]) Add(x T) {
+	a.elements = append(a.elements, x)
+}
+
+func (a *collectAggregator[T]) Remove(x T) {
+	a.elements = a.elements[1:]
+}
+
+func (a *collectAggregator[T]) Result() []T {
+	result := make([]T, len(a.elements))
+	copy(result, a.elements)
+	return result
+}
+
+type sumAggregator[N NumberType] struct {
+	sum N
+}
+
+// NewSumAggregator creates an Aggregator computing the sum of the window elements.
+func NewSumAggregator[N NumberType]() Aggregator[N, N] {
+	return &sumAggregator[N]{}
+}
+
+func (a *sumAggregator[N]) Add(x N) {
+	a.sum += x
+}
+
+func (a *sumAggregator[N]) Remove(x N) {
+	a.sum -= x
+}
+
+func (a *sumAggregator[N]) Result() N {
+	return a.sum
+}
+
+type averageAggregator[N NumberType] struct {
+	sum   N
+	count int
+}
+
+// NewAverageAggregator creates an Aggregator computing the mean of the window elements, zero for an empty window.
+func NewAverageAggregator[N NumberType]() Aggregator[N, float64] {
+	return &averageAggregator[N]{}
+}
+
+func (a *averageAggregator[N]) Add(x N) {
+	a.sum += x
+	a.count++
+}
+
+func (a *averageAggregator[N]) Remove(x N) {
+	a.sum -= x
+	a.count--
+}
+
+func (a *averageAggregator[N]) Result() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	return float64(a.sum) / float64(a.count)
+}
+
+type percentileAggregator[N NumberType] struct {
+	percentile float64
+	// sorted holds the window elements in ascending order
+	sorted []N
+}
+
+// NewPercentileAggregator creates an Aggregator computing the nearest-rank `percentile` of the window elements,
+// `percentile` ranging from 0 to 1. The Aggregator keeps the elements sorted, zero is returned for an empty window.
+func NewPercentileAggregator[N NumberType](percentile float64) Aggregator[N, N] {
+	return &percentileAggregator[N]{percentile: percentile}
+}
+
+func (a *percentileAggregator[N]) Add(x N) {
+	i := sort.Search(len(a.sorted), func(i int) bool { return a.sorted[i] >= x })
+	a.sorted = append(a.sorted, x)
+	copy(a.sorted[i+1:], a.sorted[i:])
+	a.sorted[i] = x
+}
+
+func (a *percentileAggregator[N]) Remove(x N) {
+	i := sort.Search(len(a.sorted), func(i int) bool { return a.sorted[i] >= x })
+	if i < len(a.sorted) && a.sorted[i] == x {
+		a.sorted = append(a.sorted[:i], a.sorted[i+1:]...)
+	}
+}
+
+func (a *percentileAggregator[N]) Result() N {
+	if len(a.sorted) == 0 {
+		return N(0)
+	}
+	rank := int(math.Ceil(a.percentile*float64(len(a.sorted)))) - 1
+	return a.sorted[min(max(rank, 0), len(a.sorted)-1)]
+}
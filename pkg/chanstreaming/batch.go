@@ -1,6 +1,9 @@
 package chanstreaming
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // NumberType is a type constraint for numeric types used for weighted batching
 type NumberType interface {
@@ -24,70 +27,95 @@ func BatchWeighted[T any, N NumberType](
 	maxSize N,
 	maxCount int,
 	maxInterval time.Duration,
+) func(in <-chan T) <-chan []T {
+	return BatchWeightedCtx[T, N](context.Background(), sizeFn, maxSize, maxCount, maxInterval)
+}
+
+// BatchWeightedCtx works like BatchWeighted, closing the resulting channel and stopping the ticker
+// on cancellation of the context.Context. The pending batch is discarded on cancellation.
+func BatchWeightedCtx[T any, N NumberType](
+	ctx context.Context,
+	sizeFn func(element T) N,
+	maxSize N,
+	maxCount int,
+	maxInterval time.Duration,
 ) func(in <-chan T) <-chan []T {
 	tickSize := max(maxInterval/1000, time.Millisecond*time.Duration(5))
+	clock := ClockFromContext(ctx)
 	return func(in <-chan T) <-chan []T {
 		// launch consumer that reshapes source stream into data + tick signals
 		done := make(chan struct{})
 		dataAndTickChannel := make(chan weightedBatchElement[T, N], 1)
 		go func() {
-			defer func() {
-				close(done)
-				// signal completion of original source - used to shut down the ticker
-
-			}()
-			for sourceItem := range in {
-				dataAndTickChannel <- weightedBatchElement[T, N]{
+			// signal completion of original source - used to shut down the ticker
+			defer close(done)
+			for {
+				sourceItem, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				signal := weightedBatchElement[T, N]{
 					element:     sourceItem,
 					elementSize: sizeFn(sourceItem),
 				}
+				if !sendCtx(ctx, dataAndTickChannel, signal) {
+					return
+				}
 			}
 		}()
 
 		go func() {
-			defer close(dataAndTickChannel)
-			ticker := time.Tick(tickSize)
+			defer func() {
+				// the source consumer may still be sending on cancellation, close only once it is done
+				<-done
+				close(dataAndTickChannel)
+			}()
+			ticker := clock.NewTicker(tickSize)
+			defer ticker.Stop()
 			for {
 				select {
 				case <-done:
 					// read completion of original source
 					return
-				case _, ok := <-ticker:
+				case <-ctx.Done():
+					return
+				case <-ticker.C():
 					// read a timer tick
-					if !ok {
+					if !sendCtx(ctx, dataAndTickChannel, weightedBatchElement[T, N]{tick: true}) {
 						return
 					}
-					dataAndTickChannel <- weightedBatchElement[T, N]{tick: true}
 				}
 			}
 		}()
 
 		// launch consumer for data items interleaved with timer events,
-		// write batches on buffer overflow or time.Since(lastFlush) > maxInterval on timer tick
+		// write batches on buffer overflow or clock.Now().Sub(lastFlush) > maxInterval on timer tick
 		out := make(chan []T, 1)
 		buffer := make([]T, 0, maxCount)
 		bufferSize := N(0)
-		lastFlush := time.Now()
+		lastFlush := clock.Now()
 		go func() {
 			defer close(out)
 			for signal := range dataAndTickChannel {
 				timeToFlush := false
 
-				if signal.tick && time.Since(lastFlush) > maxInterval {
+				if signal.tick && clock.Now().Sub(lastFlush) > maxInterval {
 					timeToFlush = true
 				}
 
 				if !signal.tick {
 					buffer = append(buffer, signal.element)
-					bufferSize = bufferSize + sizeFn(signal.element)
+					bufferSize = bufferSize + signal.elementSize
 					if (len(buffer) >= maxCount) || (bufferSize >= maxSize) {
 						timeToFlush = true
 					}
 				}
 
 				if timeToFlush {
-					lastFlush = time.Now()
-					out <- buffer
+					lastFlush = clock.Now()
+					if !sendCtx(ctx, out, buffer) {
+						return
+					}
 					buffer = make([]T, 0, maxCount)
 					bufferSize = N(0)
 				}
@@ -95,7 +123,7 @@ func BatchWeighted[T any, N NumberType](
 
 			// tick the remaining buffer
 			if len(buffer) > 0 {
-				out <- buffer
+				sendCtx(ctx, out, buffer)
 			}
 		}()
 
@@ -105,8 +133,13 @@ func BatchWeighted[T any, N NumberType](
 
 // Batch reshapes the source channel into channel of batches based on elapsed interval and accumulated sizes.
 func Batch[T any](maxElements int, maxInterval time.Duration) func(in <-chan T) <-chan []T {
+	return BatchCtx[T](context.Background(), maxElements, maxInterval)
+}
+
+// BatchCtx works like Batch, closing the resulting channel on cancellation of the context.Context.
+func BatchCtx[T any](ctx context.Context, maxElements int, maxInterval time.Duration) func(in <-chan T) <-chan []T {
 	weightFunc := func(element T) int {
 		return 1
 	}
-	return BatchWeighted[T, int](weightFunc, maxElements, maxElements, maxInterval)
+	return BatchWeightedCtx[T, int](ctx, weightFunc, maxElements, maxElements, maxInterval)
 }
@@ -0,0 +1,249 @@
+package chanstreaming
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // calls pass through, failures are counted
+	CircuitOpen                         // calls fail fast with ErrCircuitOpen until the cool-down elapses
+	CircuitHalfOpen                     // a limited number of probe calls decides whether to close or reopen the circuit
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrCircuitOpen is the error of the Result emitted in place of a call rejected by an open CircuitBreaker.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig defines when a CircuitBreaker opens and how it recovers.
+type CircuitBreakerConfig struct {
+	// ConsecutiveFailures opens the circuit after this many failures in a row, zero disables the check
+	ConsecutiveFailures int
+	// FailureRate opens the circuit when the share of failures among the last Window calls reaches it, zero disables the check
+	FailureRate float64
+	Window      int
+	// CoolDown is the time the circuit stays open before letting the probe calls through
+	CoolDown time.Duration
+	// HalfOpenProbes is the number of successful probe calls required to close the circuit, values below 1 mean a single probe
+	HalfOpenProbes int
+	// Clock drives the cool-down, nil means SystemClock
+	Clock Clock
+}
+
+// CircuitStateChange describes a transition of a CircuitBreaker.
+type CircuitStateChange struct {
+	From CircuitState
+	To   CircuitState
+	Time time.Time
+}
+
+// CircuitBreaker tracks the failures of calls to a dependency and rejects the calls while the dependency is considered down.
+// A single CircuitBreaker may be shared by several stages protecting the same dependency.
+type CircuitBreaker struct {
+	mu     sync.Mutex
+	config CircuitBreakerConfig
+	state  CircuitState
+	// generation is bumped on each transition so that outcomes of calls started in a previous state are ignored
+	generation  uint64
+	openedAt    time.Time
+	consecutive int
+	// outcomes is a ring of the last Window call outcomes, true meaning a failure
+	outcomes       []bool
+	outcomesNext   int
+	outcomesCount  int
+	failures       int
+	probesInFlight int
+	probesPassed   int
+	subscribers    map[chan CircuitStateChange]struct{}
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker.
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	config.HalfOpenProbes = max(config.HalfOpenProbes, 1)
+	if config.Clock == nil {
+		config.Clock = SystemClock
+	}
+	return &CircuitBreaker{
+		config:      config,
+		outcomes:    make([]bool, max(config.Window, 0)),
+		subscribers: make(map[chan CircuitStateChange]struct{}),
+	}
+}
+
+// State returns the current state of the CircuitBreaker.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// StateChanges subscribes to the transitions of the CircuitBreaker.
+// The resulting channel holds up to `buffer` pending changes, newer changes are dropped while it is full.
+// The channel is closed on cancellation of the context.Context.
+func (b *CircuitBreaker) StateChanges(ctx context.Context, buffer int) <-chan CircuitStateChange {
+	out := make(chan CircuitStateChange, buffer)
+	b.mu.Lock()
+	b.subscribers[out] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, out)
+		close(out)
+	}()
+	return out
+}
+
+// allow reports whether a call may proceed, registering it as a probe in half-open state.
+// Returns the generation to pass to record along with the call outcome.
+func (b *CircuitBreaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	switch b.state {
+	case CircuitOpen:
+		return b.generation, false
+	case CircuitHalfOpen:
+		if b.probesInFlight+b.probesPassed >= b.config.HalfOpenProbes {
+			return b.generation, false
+		}
+		b.probesInFlight++
+		return b.generation, true
+	default:
+		return b.generation, true
+	}
+}
+
+// record registers the outcome of a call permitted by allow.
+func (b *CircuitBreaker) record(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case CircuitHalfOpen:
+		b.probesInFlight--
+		if failed {
+			b.transition(CircuitOpen)
+			return
+		}
+		b.probesPassed++
+		if b.probesPassed >= b.config.HalfOpenProbes {
+			b.transition(CircuitClosed)
+		}
+	case CircuitClosed:
+		if failed {
+			b.consecutive++
+		} else {
+			b.consecutive = 0
+		}
+		b.pushOutcome(failed)
+		if b.tripped() {
+			b.transition(CircuitOpen)
+		}
+	}
+}
+
+func (b *CircuitBreaker) pushOutcome(failed bool) {
+	if len(b.outcomes) == 0 {
+		return
+	}
+	if b.outcomesCount == len(b.outcomes) {
+		if b.outcomes[b.outcomesNext] {
+			b.failures--
+		}
+	} else {
+		b.outcomesCount++
+	}
+	b.outcomes[b.outcomesNext] = failed
+	if failed {
+		b.failures++
+	}
+	b.outcomesNext = (b.outcomesNext + 1) % len(b.outcomes)
+}
+
+func (b *CircuitBreaker) tripped() bool {
+	if b.config.ConsecutiveFailures > 0 && b.consecutive >= b.config.ConsecutiveFailures {
+		return true
+	}
+	if b.config.FailureRate > 0 && b.outcomesCount > 0 && b.outcomesCount == len(b.outcomes) {
+		return float64(b.failures)/float64(b.outcomesCount) >= b.config.FailureRate
+	}
+	return false
+}
+
+// advance moves an open circuit to half-open once the cool-down has elapsed.
+func (b *CircuitBreaker) advance() {
+	if b.state == CircuitOpen && b.config.Clock.Now().Sub(b.openedAt) >= b.config.CoolDown {
+		b.transition(CircuitHalfOpen)
+	}
+}
+
+func (b *CircuitBreaker) transition(to CircuitState) {
+	change := CircuitStateChange{From: b.state, To: to, Time: b.config.Clock.Now()}
+	b.state = to
+	b.generation++
+	b.consecutive = 0
+	b.failures = 0
+	b.outcomesCount = 0
+	b.outcomesNext = 0
+	b.probesInFlight = 0
+	b.probesPassed = 0
+	if to == CircuitOpen {
+		b.openedAt = change.Time
+	}
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- change:
+		default:
+		}
+	}
+}
+
+// CallCircuitBreaker invokes `fn` through the CircuitBreaker, treating errors and panics as failures.
+// While the circuit is open `fn` is not invoked and ErrCircuitOpen is returned instead.
+func CallCircuitBreaker[R any](breaker *CircuitBreaker, fn func() (R, error)) Result[R] {
+	generation, ok := breaker.allow()
+	if !ok {
+		return NewError[R](ErrCircuitOpen)
+	}
+	data, err := tryCall(fn)
+	breaker.record(generation, err != nil)
+	if err != nil {
+		return NewError[R](err)
+	}
+	return NewResult(data)
+}
+
+// MapCircuitBreaker applies a fallible transformation function to each element in parallel, preserving order,
+// protecting the dependency behind `fn` with the CircuitBreaker.
+func MapCircuitBreaker[T any, R any](breaker *CircuitBreaker, fn func(T) (R, error), maxWorkers int) func(in <-chan T) <-chan Result[R] {
+	return MapCircuitBreakerCtx[T, R](context.Background(), breaker, fn, maxWorkers)
+}
+
+// MapCircuitBreakerCtx works like MapCircuitBreaker, closing the resulting channel on cancellation of the context.Context.
+func MapCircuitBreakerCtx[T any, R any](ctx context.Context, breaker *CircuitBreaker, fn func(T) (R, error), maxWorkers int) func(in <-chan T) <-chan Result[R] {
+	return MapCtx[T, Result[R]](ctx, func(x T) Result[R] {
+		return CallCircuitBreaker(breaker, func() (R, error) { return fn(x) })
+	}, maxWorkers)
+}
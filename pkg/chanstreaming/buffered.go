@@ -1,13 +1,157 @@
 package chanstreaming
 
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
 // Buffered returns a channel that is backed by another channel with the given size
 func Buffered[T any](size int) func(in <-chan T) <-chan T {
+	return BufferedCtx[T](context.Background(), size)
+}
+
+// BufferedCtx works like Buffered, closing the resulting channel on cancellation of the context.Context.
+func BufferedCtx[T any](ctx context.Context, size int) func(in <-chan T) <-chan T {
 	return func(in <-chan T) <-chan T {
 		out := make(chan T, size)
 		go func() {
 			defer close(out)
-			for x := range in {
-				out <- x
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if !sendCtx(ctx, out, x) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// ErrBufferOverflow is emitted by BufferedWithStrategy using the Error OverflowStrategy in place of the dropped elements.
+var ErrBufferOverflow = errors.New("buffer overflow")
+
+// BufferCounters counts the elements a strategy-backed buffer dropped or conflated, it is safe to read while the buffer runs.
+type BufferCounters struct {
+	dropped   atomic.Int64
+	conflated atomic.Int64
+}
+
+// NewBufferCounters creates zeroed BufferCounters, they can be shared across several buffers.
+func NewBufferCounters() *BufferCounters {
+	return &BufferCounters{}
+}
+
+// Dropped returns the number of elements dropped on overflow.
+func (c *BufferCounters) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Conflated returns the number of elements merged into a pending element on overflow.
+func (c *BufferCounters) Conflated() int64 {
+	return c.conflated.Load()
+}
+
+// BufferedWithStrategy buffers up to `size` elements between a fast producer and a slow consumer,
+// applying the OverflowStrategy once the buffer is full: Ignore blocks the producer like Buffered,
+// DropHead drops the oldest pending element, DropTail drops the new element
+// and Error drops the new element emitting an ErrBufferOverflow Result in its place (once per burst of overflows),
+// see BufferedConflate to merge the new element into the pending ones instead.
+// The dropped elements are counted in `counters`, which may be nil.
+func BufferedWithStrategy[T any](size int, strategy OverflowStrategy, counters *BufferCounters) func(in <-chan T) <-chan Result[T] {
+	return BufferedWithStrategyCtx[T](context.Background(), size, strategy, counters)
+}
+
+// BufferedWithStrategyCtx works like BufferedWithStrategy, closing the resulting channel on cancellation of the context.Context.
+func BufferedWithStrategyCtx[T any](ctx context.Context, size int, strategy OverflowStrategy, counters *BufferCounters) func(in <-chan T) <-chan Result[T] {
+	return bufferedWithStrategyCtx(ctx, size, strategy, nil, counters, func(r Result[T]) Result[T] { return r })
+}
+
+// BufferedConflate buffers up to `size` elements, merging the new element into the newest pending one
+// with `conflate` once the buffer is full, so the producer is never blocked and nothing is lost
+// beyond what `conflate` discards. The conflated elements are counted in `counters`, which may be nil.
+func BufferedConflate[T any](size int, conflate func(pending T, x T) T, counters *BufferCounters) func(in <-chan T) <-chan T {
+	return BufferedConflateCtx[T](context.Background(), size, conflate, counters)
+}
+
+// BufferedConflateCtx works like BufferedConflate, closing the resulting channel on cancellation of the context.Context.
+func BufferedConflateCtx[T any](ctx context.Context, size int, conflate func(pending T, x T) T, counters *BufferCounters) func(in <-chan T) <-chan T {
+	return bufferedWithStrategyCtx(ctx, size, Ignore, conflate, counters, func(r Result[T]) T { return r.Data })
+}
+
+// bufferedWithStrategyCtx holds the pending elements as Results, converting them with `output` on sending.
+// A non-nil `conflate` takes over the OverflowStrategy, merging the new element into the newest pending one.
+func bufferedWithStrategyCtx[T any, R any](ctx context.Context, size int, strategy OverflowStrategy, conflate func(pending T, x T) T, counters *BufferCounters, output func(Result[T]) R) func(in <-chan T) <-chan R {
+	size = max(size, 1)
+	if counters == nil {
+		counters = NewBufferCounters()
+	}
+	return func(in <-chan T) <-chan R {
+		// out is unbuffered, the pending elements are all held in the queue
+		out := make(chan R)
+		go func() {
+			defer close(out)
+			var queue []Result[T]
+			// pending counts the elements in the queue, not counting an overflow error
+			pending := 0
+			source := in
+			for source != nil || len(queue) > 0 {
+				receive := source
+				if strategy == Ignore && conflate == nil && pending >= size {
+					receive = nil
+				}
+				var send chan R
+				var head R
+				if len(queue) > 0 {
+					send = out
+					head = output(queue[0])
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case send <- head:
+					if queue[0].Error == nil {
+						pending--
+					}
+					queue[0] = Result[T]{}
+					queue = queue[1:]
+				case x, ok := <-receive:
+					if !ok {
+						source = nil
+						continue
+					}
+					if pending < size {
+						queue = append(queue, NewResult(x))
+						pending++
+						continue
+					}
+					if conflate != nil {
+						counters.conflated.Add(1)
+						queue[len(queue)-1] = NewResult(conflate(queue[len(queue)-1].Data, x))
+						continue
+					}
+					switch strategy {
+					case DropHead:
+						counters.dropped.Add(1)
+						for i, r := range queue {
+							if r.Error == nil {
+								queue = append(queue[:i], queue[i+1:]...)
+								break
+							}
+						}
+						queue = append(queue, NewResult(x))
+					case DropTail:
+						counters.dropped.Add(1)
+					default:
+						counters.dropped.Add(1)
+						if queue[len(queue)-1].Error == nil {
+							queue = append(queue, NewError[T](ErrBufferOverflow))
+						}
+					}
+				}
 			}
 		}()
 		return out
@@ -0,0 +1,320 @@
+package chanstreaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StateStore persists the snapshots of the stateful operators by name.
+// Implementations are safe for concurrent use, so a single StateStore can hold the snapshots of several operators.
+type StateStore interface {
+	// Save replaces the snapshot stored under the name.
+	Save(name string, snapshot []byte) error
+	// Load returns the last snapshot saved under the name, reporting false if there is none.
+	Load(name string) ([]byte, bool, error)
+}
+
+// MemoryStateStore is a StateStore holding the snapshots in memory, surviving restarts of the operators but not of the process.
+type MemoryStateStore struct {
+	mu        sync.Mutex
+	snapshots map[string][]byte
+}
+
+// NewMemoryStateStore creates an empty MemoryStateStore.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{snapshots: make(map[string][]byte)}
+}
+
+func (s *MemoryStateStore) Save(name string, snapshot []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[name] = append([]byte(nil), snapshot...)
+	return nil
+}
+
+func (s *MemoryStateStore) Load(name string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot, ok := s.snapshots[name]
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), snapshot...), true, nil
+}
+
+// FileStateStore is a StateStore keeping a file per snapshot in a local directory.
+// Snapshots are written to a temporary file first and renamed over the previous one, so a crash never leaves a torn snapshot.
+type FileStateStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileStateStore creates a FileStateStore in the directory, creating the directory if needed.
+func NewFileStateStore(dir string) (*FileStateStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStateStore{dir: dir}, nil
+}
+
+// path maps the snapshot name to a file of the store directory, escaping the path separators.
+func (s *FileStateStore) path(name string) string {
+	return filepath.Join(s.dir, url.PathEscape(name)+".snapshot")
+}
+
+func (s *FileStateStore) Save(name string, snapshot []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp, err := os.CreateTemp(s.dir, ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(snapshot); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(name))
+}
+
+func (s *FileStateStore) Load(name string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot, err := os.ReadFile(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return snapshot, true, nil
+}
+
+// SaveSnapshot encodes the state with encoding/json and saves it to the StateStore under the name.
+func SaveSnapshot[S any](store StateStore, name string, state S) error {
+	snapshot, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return store.Save(name, snapshot)
+}
+
+// LoadSnapshot loads the state saved under the name with SaveSnapshot, reporting false if there is none.
+func LoadSnapshot[S any](store StateStore, name string) (S, bool, error) {
+	var state S
+	snapshot, ok, err := store.Load(name)
+	if err != nil || !ok {
+		return state, false, err
+	}
+	if err := json.Unmarshal(snapshot, &state); err != nil {
+		return state, false, err
+	}
+	return state, true, nil
+}
+
+// CheckpointConfig defines where and how often the stateful operators checkpoint their state.
+// The state is also checkpointed when the source closes and on cancellation of the context.Context.
+type CheckpointConfig struct {
+	Store StateStore
+	// Name identifies the snapshot of the operator in the Store
+	Name string
+	// Interval checkpoints the state periodically, zero disables the periodic checkpoints
+	Interval time.Duration
+	// EveryN checkpoints the state after each N elements, zero disables the checkpoints by count
+	EveryN int
+	// OnError is invoked with the errors of saving and restoring the snapshots, nil ignores them
+	OnError func(err error)
+}
+
+func (c *CheckpointConfig) WithInterval(interval time.Duration) CheckpointConfig {
+	return CheckpointConfig{
+		Store:    c.Store,
+		Name:     c.Name,
+		Interval: interval,
+		EveryN:   c.EveryN,
+		OnError:  c.OnError,
+	}
+}
+
+func (c *CheckpointConfig) WithEveryN(everyN int) CheckpointConfig {
+	return CheckpointConfig{
+		Store:    c.Store,
+		Name:     c.Name,
+		Interval: c.Interval,
+		EveryN:   everyN,
+		OnError:  c.OnError,
+	}
+}
+
+func (c *CheckpointConfig) WithOnError(onError func(err error)) CheckpointConfig {
+	return CheckpointConfig{
+		Store:    c.Store,
+		Name:     c.Name,
+		Interval: c.Interval,
+		EveryN:   c.EveryN,
+		OnError:  onError,
+	}
+}
+
+func (c *CheckpointConfig) enabled() bool {
+	return c.Store != nil
+}
+
+func (c *CheckpointConfig) report(err error) {
+	if err != nil && c.OnError != nil {
+		c.OnError(err)
+	}
+}
+
+// checkpointer tracks when the stateful operators are due for a checkpoint
+type checkpointer struct {
+	config CheckpointConfig
+	ticker Ticker
+	count  int
+}
+
+// newCheckpointer starts the periodic checkpoints of the config, the ticker channel is nil when they are disabled.
+func newCheckpointer(ctx context.Context, config CheckpointConfig) (*checkpointer, <-chan time.Time) {
+	c := &checkpointer{config: config}
+	if !config.enabled() || config.Interval <= 0 {
+		return c, nil
+	}
+	c.ticker = ClockFromContext(ctx).NewTicker(config.Interval)
+	return c, c.ticker.C()
+}
+
+// element counts an element, reporting whether a checkpoint is due.
+func (c *checkpointer) element() bool {
+	if !c.config.enabled() || c.config.EveryN <= 0 {
+		return false
+	}
+	c.count++
+	if c.count < c.config.EveryN {
+		return false
+	}
+	c.count = 0
+	return true
+}
+
+func (c *checkpointer) stop() {
+	if c.ticker != nil {
+		c.ticker.Stop()
+	}
+}
+
+// restoreCheckpoint loads the state of the operator from the Store, falling back to `initialState`.
+func restoreCheckpoint[S any](config CheckpointConfig, initialState S) S {
+	if !config.enabled() {
+		return initialState
+	}
+	state, ok, err := LoadSnapshot[S](config.Store, config.Name)
+	config.report(err)
+	if !ok {
+		return initialState
+	}
+	return state
+}
+
+func saveCheckpoint[S any](config CheckpointConfig, state S) {
+	if config.enabled() {
+		config.report(SaveSnapshot(config.Store, config.Name, state))
+	}
+}
+
+// scanCheckpointedCtx runs Scan or, with `fold` set, Fold checkpointing the state.
+func scanCheckpointedCtx[TIn any, TState any](ctx context.Context, fn func(TState, TIn) TState, initialState TState, config CheckpointConfig, fold bool) func(in <-chan TIn) <-chan TState {
+	return func(in <-chan TIn) <-chan TState {
+		out := make(chan TState, 1)
+		go func() {
+			defer close(out)
+			state := restoreCheckpoint(config, initialState)
+			checkpoints, tick := newCheckpointer(ctx, config)
+			defer checkpoints.stop()
+			for {
+				select {
+				case <-ctx.Done():
+					saveCheckpoint(config, state)
+					return
+				case <-tick:
+					saveCheckpoint(config, state)
+				case x, ok := <-in:
+					if !ok {
+						saveCheckpoint(config, state)
+						if fold {
+							sendCtx(ctx, out, state)
+						}
+						return
+					}
+					state = fn(state, x)
+					if checkpoints.element() {
+						saveCheckpoint(config, state)
+					}
+					if !fold && !sendCtx(ctx, out, state) {
+						saveCheckpoint(config, state)
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// ScanCheckpointed works like Scan, checkpointing the state into the config Store and resuming from its last snapshot.
+// The state is encoded with encoding/json, see SaveSnapshot.
+func ScanCheckpointed[TIn any, TState any](fn func(TState, TIn) TState, initialState TState, config CheckpointConfig) func(in <-chan TIn) <-chan TState {
+	return ScanCheckpointedCtx[TIn, TState](context.Background(), fn, initialState, config)
+}
+
+// ScanCheckpointedCtx works like ScanCheckpointed, closing the resulting channel on cancellation of the context.Context.
+func ScanCheckpointedCtx[TIn any, TState any](ctx context.Context, fn func(TState, TIn) TState, initialState TState, config CheckpointConfig) func(in <-chan TIn) <-chan TState {
+	return scanCheckpointedCtx[TIn, TState](ctx, fn, initialState, config, false)
+}
+
+// FoldCheckpointed works like Fold, checkpointing the state into the config Store and resuming from its last snapshot.
+// The state is encoded with encoding/json, see SaveSnapshot.
+func FoldCheckpointed[TIn any, TState any](fn func(TState, TIn) TState, initialState TState, config CheckpointConfig) func(in <-chan TIn) <-chan TState {
+	return FoldCheckpointedCtx[TIn, TState](context.Background(), fn, initialState, config)
+}
+
+// FoldCheckpointedCtx works like FoldCheckpointed, closing the resulting channel on cancellation of the context.Context.
+func FoldCheckpointedCtx[TIn any, TState any](ctx context.Context, fn func(TState, TIn) TState, initialState TState, config CheckpointConfig) func(in <-chan TIn) <-chan TState {
+	return scanCheckpointedCtx[TIn, TState](ctx, fn, initialState, config, true)
+}
+
+// ScanByKeyCheckpointed works like ScanByKey, checkpointing the states of all the keys into the checkpoint Store
+// and resuming from its last snapshot. The states are encoded with encoding/json as a list of KeyedState.
+func ScanByKeyCheckpointed[K comparable, TIn any, TState any](keyFn func(TIn) K, fn func(TState, TIn) TState, initialState TState, config KeyedStateConfig, checkpoint CheckpointConfig) func(in <-chan TIn) <-chan KeyedState[K, TState] {
+	return ScanByKeyCheckpointedCtx[K, TIn, TState](context.Background(), keyFn, fn, initialState, config, checkpoint)
+}
+
+// ScanByKeyCheckpointedCtx works like ScanByKeyCheckpointed, closing the resulting channel on cancellation of the context.Context.
+func ScanByKeyCheckpointedCtx[K comparable, TIn any, TState any](ctx context.Context, keyFn func(TIn) K, fn func(TState, TIn) TState, initialState TState, config KeyedStateConfig, checkpoint CheckpointConfig) func(in <-chan TIn) <-chan KeyedState[K, TState] {
+	return keyedStateCtx[K, TIn, TState](ctx, keyFn, foldFromInitial(fn, initialState), config, checkpoint, false)
+}
+
+// FoldByKeyCheckpointed works like FoldByKey, checkpointing the states of all the keys into the checkpoint Store
+// and resuming from its last snapshot. The states are encoded with encoding/json as a list of KeyedState.
+// The states emitted when the source closes are dropped from the snapshot, a restart after completion starts over.
+func FoldByKeyCheckpointed[K comparable, TIn any, TState any](keyFn func(TIn) K, fn func(TState, TIn) TState, initialState TState, config KeyedStateConfig, checkpoint CheckpointConfig) func(in <-chan TIn) <-chan KeyedState[K, TState] {
+	return FoldByKeyCheckpointedCtx[K, TIn, TState](context.Background(), keyFn, fn, initialState, config, checkpoint)
+}
+
+// FoldByKeyCheckpointedCtx works like FoldByKeyCheckpointed, closing the resulting channel on cancellation of the context.Context.
+func FoldByKeyCheckpointedCtx[K comparable, TIn any, TState any](ctx context.Context, keyFn func(TIn) K, fn func(TState, TIn) TState, initialState TState, config KeyedStateConfig, checkpoint CheckpointConfig) func(in <-chan TIn) <-chan KeyedState[K, TState] {
+	return keyedStateCtx[K, TIn, TState](ctx, keyFn, foldFromInitial(fn, initialState), config, checkpoint, true)
+}
@@ -0,0 +1,242 @@
+package chanstreaming
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the timing operators.
+// The Ctx operators take the Clock from the context.Context, see ContextWithClock, and use SystemClock by default.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer mirrors time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker mirrors time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the Clock backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+type systemTimer struct{ *time.Timer }
+
+type systemTicker struct{ *time.Ticker }
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+type clockContextKey struct{}
+
+// ContextWithClock returns a copy of the context.Context carrying the Clock for the Ctx operators.
+func ContextWithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, clock)
+}
+
+// ClockFromContext returns the Clock carried by the context.Context or SystemClock.
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return clock
+	}
+	return SystemClock
+}
+
+// ManualClock is a virtual Clock that only moves when advanced, making the timing operators deterministic in tests.
+type ManualClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	waiters manualWaiters
+	// created numbers the waiters, so that the ones due at the same time fire in order of creation
+	created uint64
+}
+
+// manualWaiter is a timer or a ticker (when period > 0) of the ManualClock
+type manualWaiter struct {
+	clock  *ManualClock
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+	seq    uint64
+	// index is the position of the waiter in the heap, -1 once it is removed
+	index int
+}
+
+// manualWaiters is a min-heap of the waiters ordered by their due time
+type manualWaiters []*manualWaiter
+
+func (w manualWaiters) Len() int {
+	return len(w)
+}
+
+func (w manualWaiters) Less(i, j int) bool {
+	if w[i].at.Equal(w[j].at) {
+		return w[i].seq < w[j].seq
+	}
+	return w[i].at.Before(w[j].at)
+}
+
+func (w manualWaiters) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+	w[i].index = i
+	w[j].index = j
+}
+
+func (w *manualWaiters) Push(x any) {
+	waiter := x.(*manualWaiter)
+	waiter.index = len(*w)
+	*w = append(*w, waiter)
+}
+
+func (w *manualWaiters) Pop() any {
+	old := *w
+	waiter := old[len(old)-1]
+	old[len(old)-1] = nil
+	waiter.index = -1
+	*w = old[:len(old)-1]
+	return waiter
+}
+
+// NewManualClock creates a ManualClock set to `now`.
+func NewManualClock(now time.Time) *ManualClock {
+	clock := &ManualClock{now: now}
+	clock.changed = sync.NewCond(&clock.mu)
+	return clock
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	return manualTimer{c.addWaiter(d, 0)}
+}
+
+func (c *ManualClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return manualTicker{c.addWaiter(d, d)}
+}
+
+func (c *ManualClock) addWaiter(d time.Duration, period time.Duration) *manualWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	waiter := &manualWaiter{
+		clock:  c,
+		at:     c.now.Add(d),
+		period: period,
+		c:      make(chan time.Time, 1),
+		seq:    c.created,
+		index:  -1,
+	}
+	c.created++
+	if d <= 0 && period == 0 {
+		waiter.c <- c.now
+		return waiter
+	}
+	heap.Push(&c.waiters, waiter)
+	c.changed.Broadcast()
+	return waiter
+}
+
+// Advance moves the ManualClock forward by `d`, firing the timers and tickers that are due in order.
+// Like time.Ticker, a ticker drops the ticks its reader is not keeping up with.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target := c.now.Add(d)
+	for len(c.waiters) > 0 && !c.waiters[0].at.After(target) {
+		waiter := c.waiters[0]
+		c.now = waiter.at
+		select {
+		case waiter.c <- c.now:
+		default:
+		}
+		if waiter.period > 0 {
+			waiter.at = waiter.at.Add(waiter.period)
+			heap.Fix(&c.waiters, 0)
+		} else {
+			heap.Pop(&c.waiters)
+		}
+	}
+	c.now = target
+	c.changed.Broadcast()
+}
+
+// BlockUntil blocks until at least `n` timers and tickers are waiting on the ManualClock.
+// Use it to make sure the operators under test have reached their waiting point before calling Advance.
+func (c *ManualClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.changed.Wait()
+	}
+}
+
+// Waiters returns the number of timers and tickers waiting on the ManualClock.
+func (c *ManualClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// remove detaches the waiter from the ManualClock, reporting whether it was still pending.
+func (c *ManualClock) remove(waiter *manualWaiter) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if waiter.index < 0 {
+		return false
+	}
+	heap.Remove(&c.waiters, waiter.index)
+	c.changed.Broadcast()
+	return true
+}
+
+type manualTimer struct{ *manualWaiter }
+
+type manualTicker struct{ *manualWaiter }
+
+func (w *manualWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (t manualTimer) Stop() bool {
+	return t.clock.remove(t.manualWaiter)
+}
+
+func (t manualTicker) Stop() {
+	t.clock.remove(t.manualWaiter)
+}
@@ -1,12 +1,21 @@
 package chanstreaming
 
+import "context"
+
 // FromSlice creates a readonly channel from a slice
 func FromSlice[T any](data []T) <-chan T {
+	return FromSliceCtx(context.Background(), data)
+}
+
+// FromSliceCtx works like FromSlice, closing the resulting channel on cancellation of the context.Context.
+func FromSliceCtx[T any](ctx context.Context, data []T) <-chan T {
 	output := make(chan T, 1)
 	go func() {
 		defer close(output)
 		for _, v := range data {
-			output <- v
+			if !sendCtx(ctx, output, v) {
+				return
+			}
 		}
 	}()
 	return output
@@ -26,6 +35,19 @@ func ToSlice[T any](in <-chan T) []T {
 	return collected
 }
 
+// ToSliceCtx collects elements from the channel until it closes or the context.Context is cancelled.
+// Returns the elements collected so far and the context error, if any.
+func ToSliceCtx[T any](ctx context.Context, in <-chan T) ([]T, error) {
+	collected := make([]T, 0)
+	for {
+		x, ok := recvCtx(ctx, in)
+		if !ok {
+			return collected, ctx.Err()
+		}
+		collected = append(collected, x)
+	}
+}
+
 // ToSet collects all elements from the channel and returns them as a map with the elements as keys
 func ToSet[T comparable](in <-chan T) map[T]struct{} {
 	collected := make(map[T]struct{}, 0)
@@ -36,12 +58,23 @@ func ToSet[T comparable](in <-chan T) map[T]struct{} {
 }
 
 // CollectWhile collects elements from the source channel while the `predicate` function is true,
-// returning the slice of collected elements and the `tail` channel that starts with the first element that failed the `predicate` check
+// returning the slice of collected elements and the `tail` channel that starts with the first element that failed the `predicate` check.
+// The `tail` owns the rest of the source: it must be consumed or passed to Drain.
 func CollectWhile[T any](predicate func(T) bool) func(in <-chan T) ([]T, <-chan T) {
+	return CollectWhileCtx[T](context.Background(), predicate)
+}
+
+// CollectWhileCtx works like CollectWhile, stopping the collection and closing the `tail` channel
+// on cancellation of the context.Context.
+func CollectWhileCtx[T any](ctx context.Context, predicate func(T) bool) func(in <-chan T) ([]T, <-chan T) {
 	return func(in <-chan T) ([]T, <-chan T) {
 		out := make(chan T, 1)
 		collected := make([]T, 0)
-		for x := range in {
+		for {
+			x, ok := recvCtx(ctx, in)
+			if !ok {
+				break
+			}
 			if predicate(x) {
 				collected = append(collected, x)
 			} else {
@@ -52,8 +85,14 @@ func CollectWhile[T any](predicate func(T) bool) func(in <-chan T) ([]T, <-chan
 
 		go func() {
 			defer close(out)
-			for x := range in {
-				out <- x
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if !sendCtx(ctx, out, x) {
+					return
+				}
 			}
 		}()
 		return collected, out
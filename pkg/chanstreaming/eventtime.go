@@ -0,0 +1,309 @@
+package chanstreaming
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Window is a closed window of elements emitted by the windowing operators.
+type Window[T any] struct {
+	Start    time.Time
+	End      time.Time
+	Elements []T
+}
+
+// EventTimeConfig defines how the event-time windowing operators track the progress of event time.
+// The watermark trails the max seen timestamp by MaxOutOfOrderness, a window is emitted once the watermark passes its end.
+// A window keeps accepting late elements for AllowedLateness after that, emitting an updated window for each of them;
+// elements arriving later than that are sent to the late elements channel.
+type EventTimeConfig struct {
+	MaxOutOfOrderness time.Duration
+	AllowedLateness   time.Duration
+}
+
+func (c *EventTimeConfig) WithMaxOutOfOrderness(maxOutOfOrderness time.Duration) EventTimeConfig {
+	return EventTimeConfig{
+		MaxOutOfOrderness: maxOutOfOrderness,
+		AllowedLateness:   c.AllowedLateness,
+	}
+}
+
+func (c *EventTimeConfig) WithAllowedLateness(allowedLateness time.Duration) EventTimeConfig {
+	return EventTimeConfig{
+		MaxOutOfOrderness: c.MaxOutOfOrderness,
+		AllowedLateness:   allowedLateness,
+	}
+}
+
+type eventTimeWindowKind int
+
+const (
+	tumblingEventTime eventTimeWindowKind = iota
+	hoppingEventTime
+	slidingEventTime
+	sessionEventTime
+)
+
+// eventTimeWindow is an open window of the event-time windowing state
+type eventTimeWindow[T any] struct {
+	start time.Time
+	end   time.Time
+	// closesAt is the watermark at which the window is complete
+	closesAt time.Time
+	elements []timedWindowElement[T]
+	// pending is set when the window has elements not emitted yet
+	pending bool
+}
+
+// eventTimeWindows holds the state of the event-time windowing operators
+type eventTimeWindows[T any] struct {
+	kind      eventTimeWindowKind
+	size      time.Duration
+	slide     time.Duration
+	config    EventTimeConfig
+	started   bool
+	maxTs     time.Time
+	watermark time.Time
+	windows   []*eventTimeWindow[T]
+	// retained elements are used to fill new sliding windows
+	retained []timedWindowElement[T]
+}
+
+// expired reports whether the window closing at `closesAt` no longer accepts elements.
+func (s *eventTimeWindows[T]) expired(closesAt time.Time) bool {
+	return !s.watermark.Before(closesAt.Add(s.config.AllowedLateness))
+}
+
+// window finds or creates the window with the given bounds.
+func (s *eventTimeWindows[T]) window(start time.Time, end time.Time, closesAt time.Time) (*eventTimeWindow[T], bool) {
+	for _, w := range s.windows {
+		if w.start.Equal(start) && w.end.Equal(end) {
+			return w, false
+		}
+	}
+	w := &eventTimeWindow[T]{start: start, end: end, closesAt: closesAt}
+	s.windows = append(s.windows, w)
+	return w, true
+}
+
+// add assigns the element to its windows, reporting false if the element is late for all of them.
+// An element no window covers, falling between the hopping windows when `slide` exceeds `size`, is dropped and not late.
+func (s *eventTimeWindows[T]) add(x timedWindowElement[T]) bool {
+	if !s.started || x.ts.After(s.maxTs) {
+		s.started = true
+		s.maxTs = x.ts
+		s.watermark = x.ts.Add(-s.config.MaxOutOfOrderness)
+	}
+
+	accepted := false
+	switch s.kind {
+	case tumblingEventTime, hoppingEventTime:
+		// hopping windows start every `slide`, tumbling ones have `slide` equal to `size`
+		covered := false
+		for start := x.ts.Truncate(s.slide); start.Add(s.size).After(x.ts); start = start.Add(-s.slide) {
+			covered = true
+			end := start.Add(s.size)
+			if s.expired(end) {
+				continue
+			}
+			w, _ := s.window(start, end, end)
+			w.elements = append(w.elements, x)
+			w.pending = true
+			accepted = true
+		}
+		if !covered {
+			return true
+		}
+	case slidingEventTime:
+		// each element closes its own window of the elements within `size` before it, inclusive
+		for _, w := range s.windows {
+			if !x.ts.Before(w.start) && !x.ts.After(w.end) && !s.expired(w.closesAt) {
+				w.elements = append(w.elements, x)
+				w.pending = true
+				accepted = true
+			}
+		}
+		closesAt := x.ts.Add(time.Nanosecond)
+		if !s.expired(closesAt) {
+			w, created := s.window(x.ts.Add(-s.size), x.ts, closesAt)
+			if created {
+				for _, r := range s.retained {
+					if !r.ts.Before(w.start) && !r.ts.After(w.end) {
+						w.elements = append(w.elements, r)
+					}
+				}
+				w.elements = append(w.elements, x)
+			}
+			w.pending = true
+			accepted = true
+		}
+		if accepted {
+			s.retained = append(s.retained, x)
+		}
+	case sessionEventTime:
+		// merge all the sessions overlapping with the gap following the element
+		start, end := x.ts, x.ts.Add(s.size)
+		if s.expired(end) {
+			break
+		}
+		merged := &eventTimeWindow[T]{start: start, end: end, elements: []timedWindowElement[T]{x}, pending: true}
+		kept := s.windows[:0]
+		for _, w := range s.windows {
+			if w.start.Before(merged.end) && merged.start.Before(w.end) {
+				merged.start = minTime(merged.start, w.start)
+				merged.end = maxTime(merged.end, w.end)
+				merged.elements = append(merged.elements, w.elements...)
+			} else {
+				kept = append(kept, w)
+			}
+		}
+		merged.closesAt = merged.end
+		sort.SliceStable(merged.elements, func(i, j int) bool {
+			return merged.elements[i].ts.Before(merged.elements[j].ts)
+		})
+		s.windows = append(kept, merged)
+		accepted = true
+	}
+	return accepted
+}
+
+// fire returns the pending windows complete by the watermark, dropping the windows past the allowed lateness.
+// All pending windows are returned when `flush` is set.
+func (s *eventTimeWindows[T]) fire(flush bool) []Window[T] {
+	sort.SliceStable(s.windows, func(i, j int) bool {
+		return s.windows[i].closesAt.Before(s.windows[j].closesAt)
+	})
+	fired := []Window[T]{}
+	kept := s.windows[:0]
+	for _, w := range s.windows {
+		if w.pending && (flush || !s.watermark.Before(w.closesAt)) {
+			w.pending = false
+			elements := make([]T, len(w.elements))
+			for i, e := range w.elements {
+				elements[i] = e.data
+			}
+			fired = append(fired, Window[T]{Start: w.start, End: w.end, Elements: elements})
+		}
+		if flush || !s.expired(w.closesAt) {
+			kept = append(kept, w)
+		}
+	}
+	s.windows = kept
+
+	if s.kind == slidingEventTime {
+		retained := s.retained[:0]
+		for _, r := range s.retained {
+			if !s.expired(r.ts.Add(s.size + time.Nanosecond)) {
+				retained = append(retained, r)
+			}
+		}
+		s.retained = retained
+	}
+	return fired
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// eventTimeWindowsCtx launches the event-time windowing of the source channel, starting from a copy of the `initial` state.
+func eventTimeWindowsCtx[T any](ctx context.Context, timestamp func(T) time.Time, initial eventTimeWindows[T]) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	if initial.size <= 0 || ((initial.kind == tumblingEventTime || initial.kind == hoppingEventTime) && initial.slide <= 0) {
+		panic("window size and slide must be positive")
+	}
+	return func(in <-chan T) (<-chan Window[T], <-chan T) {
+		state := initial
+		out := make(chan Window[T], 1)
+		late := make(chan T, 1)
+		go func() {
+			defer close(late)
+			defer close(out)
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					break
+				}
+				if !state.add(timedWindowElement[T]{data: x, ts: timestamp(x)}) {
+					if !sendCtx(ctx, late, x) {
+						return
+					}
+					continue
+				}
+				for _, w := range state.fire(false) {
+					if !sendCtx(ctx, out, w) {
+						return
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			// the source is complete, so is every window
+			for _, w := range state.fire(true) {
+				if !sendCtx(ctx, out, w) {
+					return
+				}
+			}
+		}()
+		return out, late
+	}
+}
+
+// EventTimeTumbling groups the source elements into consecutive non-overlapping windows of `size`
+// by the event time extracted with `timestamp`.
+// Returns the channel of windows and the channel of late elements, both must be consumed or passed to Drain.
+func EventTimeTumbling[T any](timestamp func(T) time.Time, size time.Duration, config EventTimeConfig) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	return EventTimeTumblingCtx[T](context.Background(), timestamp, size, config)
+}
+
+// EventTimeTumblingCtx works like EventTimeTumbling, closing the resulting channels on cancellation of the context.Context.
+func EventTimeTumblingCtx[T any](ctx context.Context, timestamp func(T) time.Time, size time.Duration, config EventTimeConfig) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	return eventTimeWindowsCtx(ctx, timestamp, eventTimeWindows[T]{kind: tumblingEventTime, size: size, slide: size, config: config})
+}
+
+// EventTimeHopping groups the source elements into windows of `size` starting every `slide`
+// by the event time extracted with `timestamp`, an element belongs to every window covering its timestamp.
+// Returns the channel of windows and the channel of late elements, both must be consumed or passed to Drain.
+func EventTimeHopping[T any](timestamp func(T) time.Time, size time.Duration, slide time.Duration, config EventTimeConfig) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	return EventTimeHoppingCtx[T](context.Background(), timestamp, size, slide, config)
+}
+
+// EventTimeHoppingCtx works like EventTimeHopping, closing the resulting channels on cancellation of the context.Context.
+func EventTimeHoppingCtx[T any](ctx context.Context, timestamp func(T) time.Time, size time.Duration, slide time.Duration, config EventTimeConfig) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	return eventTimeWindowsCtx(ctx, timestamp, eventTimeWindows[T]{kind: hoppingEventTime, size: size, slide: slide, config: config})
+}
+
+// EventTimeSliding emits a window for each source element, holding the elements with event time
+// within `size` before it, both ends inclusive.
+// Returns the channel of windows and the channel of late elements, both must be consumed or passed to Drain.
+func EventTimeSliding[T any](timestamp func(T) time.Time, size time.Duration, config EventTimeConfig) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	return EventTimeSlidingCtx[T](context.Background(), timestamp, size, config)
+}
+
+// EventTimeSlidingCtx works like EventTimeSliding, closing the resulting channels on cancellation of the context.Context.
+func EventTimeSlidingCtx[T any](ctx context.Context, timestamp func(T) time.Time, size time.Duration, config EventTimeConfig) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	return eventTimeWindowsCtx(ctx, timestamp, eventTimeWindows[T]{kind: slidingEventTime, size: size, config: config})
+}
+
+// EventTimeSession groups the source elements into sessions of activity separated by at least `gap` of event time.
+// A session window ends `gap` after its last element.
+// Returns the channel of windows and the channel of late elements, both must be consumed or passed to Drain.
+func EventTimeSession[T any](timestamp func(T) time.Time, gap time.Duration, config EventTimeConfig) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	return EventTimeSessionCtx[T](context.Background(), timestamp, gap, config)
+}
+
+// EventTimeSessionCtx works like EventTimeSession, closing the resulting channels on cancellation of the context.Context.
+func EventTimeSessionCtx[T any](ctx context.Context, timestamp func(T) time.Time, gap time.Duration, config EventTimeConfig) func(in <-chan T) (<-chan Window[T], <-chan T) {
+	return eventTimeWindowsCtx(ctx, timestamp, eventTimeWindows[T]{kind: sessionEventTime, size: gap, config: config})
+}
@@ -1,14 +1,31 @@
 package chanstreaming
 
+import "context"
+
 func FlatMap[T any, R any](f func(v T) <-chan R) func(in <-chan T) <-chan R {
+	return FlatMapCtx[T, R](context.Background(), f)
+}
+
+// FlatMapCtx works like FlatMap, closing the resulting channel on cancellation of the context.Context.
+func FlatMapCtx[T any, R any](ctx context.Context, f func(v T) <-chan R) func(in <-chan T) <-chan R {
 	return func(in <-chan T) <-chan R {
 		out := make(chan R, 1)
 		go func() {
 			defer close(out)
-			for v := range in {
+			for {
+				v, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
 				theChannel := f(v)
-				for x := range theChannel {
-					out <- x
+				for {
+					x, ok := recvCtx(ctx, theChannel)
+					if !ok {
+						break
+					}
+					if !sendCtx(ctx, out, x) {
+						return
+					}
 				}
 			}
 		}()
@@ -17,14 +34,25 @@ func FlatMap[T any, R any](f func(v T) <-chan R) func(in <-chan T) <-chan R {
 }
 
 func FlatMapSlice[T any, R any](f func(v T) []R) func(in <-chan T) <-chan R {
+	return FlatMapSliceCtx[T, R](context.Background(), f)
+}
+
+// FlatMapSliceCtx works like FlatMapSlice, closing the resulting channel on cancellation of the context.Context.
+func FlatMapSliceCtx[T any, R any](ctx context.Context, f func(v T) []R) func(in <-chan T) <-chan R {
 	return func(in <-chan T) <-chan R {
 		out := make(chan R, 1)
 		go func() {
 			defer close(out)
-			for v := range in {
+			for {
+				v, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
 				theSlice := f(v)
 				for _, x := range theSlice {
-					out <- x
+					if !sendCtx(ctx, out, x) {
+						return
+					}
 				}
 			}
 		}()
@@ -37,6 +65,11 @@ func Identity[T any](v T) T {
 }
 
 func Concat[T any](channels ...<-chan T) <-chan T {
-	channelOfChannels := FromSlice(channels)
-	return FlatMap(Identity[<-chan T])(channelOfChannels)
+	return ConcatCtx(context.Background(), channels...)
+}
+
+// ConcatCtx works like Concat, closing the resulting channel on cancellation of the context.Context.
+func ConcatCtx[T any](ctx context.Context, channels ...<-chan T) <-chan T {
+	channelOfChannels := FromSliceCtx(ctx, channels)
+	return FlatMapCtx(ctx, Identity[<-chan T])(channelOfChannels)
 }
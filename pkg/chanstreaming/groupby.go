@@ -0,0 +1,212 @@
+package chanstreaming
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group is the substream of the source elements sharing the Key.
+type Group[K comparable, T any] struct {
+	Key      K
+	Elements <-chan T
+}
+
+// GroupByConfig limits the groups of GroupBy.
+type GroupByConfig struct {
+	// MaxGroups limits the number of open groups, zero means unbounded
+	MaxGroups int
+	// OverflowStrategy decides what happens to an element opening a group beyond MaxGroups:
+	// Ignore opens it anyway, Error panics, DropTail drops the element and DropHead closes the least recently active group first
+	OverflowStrategy OverflowStrategy
+	// IdleTimeout closes a group that received no elements for this long, zero means never
+	IdleTimeout time.Duration
+}
+
+func (c *GroupByConfig) WithMaxGroups(maxGroups int) GroupByConfig {
+	return GroupByConfig{
+		MaxGroups:        maxGroups,
+		OverflowStrategy: c.OverflowStrategy,
+		IdleTimeout:      c.IdleTimeout,
+	}
+}
+
+func (c *GroupByConfig) WithOverflowStrategy(overflowStrategy OverflowStrategy) GroupByConfig {
+	return GroupByConfig{
+		MaxGroups:        c.MaxGroups,
+		OverflowStrategy: overflowStrategy,
+		IdleTimeout:      c.IdleTimeout,
+	}
+}
+
+func (c *GroupByConfig) WithIdleTimeout(idleTimeout time.Duration) GroupByConfig {
+	return GroupByConfig{
+		MaxGroups:        c.MaxGroups,
+		OverflowStrategy: c.OverflowStrategy,
+		IdleTimeout:      idleTimeout,
+	}
+}
+
+// openGroup is a group of GroupBy still accepting elements
+type openGroup[T any] struct {
+	elements chan T
+	last     time.Time
+}
+
+// GroupBy splits the source into a substream per distinct key extracted with `keyFn`, emitting a Group as each key is first seen.
+// A key seen again after its group was closed by the config limits opens a new Group.
+// The groups and their elements are dispatched by a single goroutine, so every Group must be consumed or passed to Drain,
+// a group left unread blocks all the others.
+func GroupBy[K comparable, T any](keyFn func(T) K, config GroupByConfig) func(in <-chan T) <-chan Group[K, T] {
+	return GroupByCtx[K, T](context.Background(), keyFn, config)
+}
+
+// GroupByCtx works like GroupBy, closing the resulting channel and all the groups on cancellation of the context.Context.
+func GroupByCtx[K comparable, T any](ctx context.Context, keyFn func(T) K, config GroupByConfig) func(in <-chan T) <-chan Group[K, T] {
+	return func(in <-chan T) <-chan Group[K, T] {
+		out := make(chan Group[K, T], 1)
+		go func() {
+			groups := make(map[K]*openGroup[T])
+			defer func() {
+				for _, g := range groups {
+					close(g.elements)
+				}
+				close(out)
+			}()
+			clock := ClockFromContext(ctx)
+			closeGroup := func(key K) {
+				close(groups[key].elements)
+				delete(groups, key)
+			}
+
+			// the timer wakes up at the earliest expiry at the time it was set, groups active since then are checked again
+			var timer Timer
+			var timerC <-chan time.Time
+			schedule := func() {
+				if timer != nil {
+					timer.Stop()
+					timer, timerC = nil, nil
+				}
+				if config.IdleTimeout <= 0 || len(groups) == 0 {
+					return
+				}
+				var next time.Time
+				first := true
+				for _, g := range groups {
+					if first || g.last.Before(next) {
+						next, first = g.last, false
+					}
+				}
+				timer = clock.NewTimer(next.Add(config.IdleTimeout).Sub(clock.Now()))
+				timerC = timer.C()
+			}
+			defer func() {
+				if timer != nil {
+					timer.Stop()
+				}
+			}()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-timerC:
+					now := clock.Now()
+					for key, g := range groups {
+						if now.Sub(g.last) >= config.IdleTimeout {
+							closeGroup(key)
+						}
+					}
+					schedule()
+				case x, ok := <-in:
+					if !ok {
+						return
+					}
+					now := clock.Now()
+					key := keyFn(x)
+					g, found := groups[key]
+					if found && config.IdleTimeout > 0 && now.Sub(g.last) >= config.IdleTimeout {
+						// the group is expired, even though the timer has not been handled yet
+						closeGroup(key)
+						found = false
+					}
+					if !found {
+						if config.MaxGroups > 0 && len(groups) >= config.MaxGroups {
+							switch config.OverflowStrategy {
+							case Ignore:
+							case DropTail:
+								continue
+							case DropHead:
+								var idleKey K
+								var idle *openGroup[T]
+								for k, open := range groups {
+									if idle == nil || open.last.Before(idle.last) {
+										idleKey, idle = k, open
+									}
+								}
+								closeGroup(idleKey)
+							case Error:
+								fallthrough
+							default:
+								panic(NewWindowOverflowError())
+							}
+						}
+						g = &openGroup[T]{elements: make(chan T, 1), last: now}
+						groups[key] = g
+						if timer == nil {
+							schedule()
+						}
+						if !sendCtx(ctx, out, Group[K, T]{Key: key, Elements: g.elements}) {
+							return
+						}
+					}
+					g.last = now
+					if !sendCtx(ctx, g.elements, x) {
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// MergeGroups runs the stage returned by `fn` on each Group independently, merging the outputs of all the stages.
+// The resulting channel is closed once the source of groups and all the stages are done.
+func MergeGroups[K comparable, T any, R any](fn func(key K, elements <-chan T) <-chan R) func(in <-chan Group[K, T]) <-chan R {
+	return MergeGroupsCtx[K, T, R](context.Background(), fn)
+}
+
+// MergeGroupsCtx works like MergeGroups, closing the resulting channel on cancellation of the context.Context.
+func MergeGroupsCtx[K comparable, T any, R any](ctx context.Context, fn func(key K, elements <-chan T) <-chan R) func(in <-chan Group[K, T]) <-chan R {
+	return func(in <-chan Group[K, T]) <-chan R {
+		out := make(chan R, 1)
+		go func() {
+			wg := sync.WaitGroup{}
+			defer func() {
+				wg.Wait()
+				close(out)
+			}()
+			for {
+				group, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				wg.Add(1)
+				go func(stage <-chan R) {
+					defer wg.Done()
+					for {
+						x, ok := recvCtx(ctx, stage)
+						if !ok {
+							return
+						}
+						if !sendCtx(ctx, out, x) {
+							return
+						}
+					}
+				}(fn(group.Key, group.Elements))
+			}
+		}()
+		return out
+	}
+}
@@ -0,0 +1,208 @@
+package chanstreaming
+
+import (
+	"context"
+	"sync"
+)
+
+// Broadcast sends every element of the source to each of the `n` resulting channels.
+// Every output must be consumed or passed to Drain, an output left unread blocks all the others.
+func Broadcast[T any](n int) func(in <-chan T) []<-chan T {
+	return BroadcastCtx[T](context.Background(), n)
+}
+
+// BroadcastCtx works like Broadcast, closing all the outputs on cancellation of the context.Context.
+func BroadcastCtx[T any](ctx context.Context, n int) func(in <-chan T) []<-chan T {
+	return func(in <-chan T) []<-chan T {
+		outs := make([]chan T, n)
+		for i := range outs {
+			outs[i] = make(chan T, 1)
+		}
+		go func() {
+			defer func() {
+				for _, out := range outs {
+					close(out)
+				}
+			}()
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				for _, out := range outs {
+					if !sendCtx(ctx, out, x) {
+						return
+					}
+				}
+			}
+		}()
+
+		readOnlyOuts := make([]<-chan T, n)
+		for i, out := range outs {
+			readOnlyOuts[i] = out
+		}
+		return readOnlyOuts
+	}
+}
+
+// hubSubscriber is a subscription to a Hub
+type hubSubscriber[T any] struct {
+	// mu guards the sends to out against closing it
+	mu       sync.Mutex
+	out      chan T
+	strategy OverflowStrategy
+	// done is closed on unsubscribing, releasing a blocked send
+	done     chan struct{}
+	doneOnce sync.Once
+	closed   bool
+}
+
+// deliver sends the element to the subscriber according to its OverflowStrategy,
+// reporting false if the subscriber is to be disconnected.
+func (s *hubSubscriber[T]) deliver(ctx context.Context, x T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return true
+	}
+	switch s.strategy {
+	case Ignore:
+		select {
+		case s.out <- x:
+		case <-s.done:
+		case <-ctx.Done():
+		}
+		return true
+	case DropHead:
+		for {
+			select {
+			case s.out <- x:
+				return true
+			default:
+			}
+			select {
+			case <-s.out:
+			default:
+			}
+		}
+	case DropTail:
+		select {
+		case s.out <- x:
+		default:
+		}
+		return true
+	default:
+		select {
+		case s.out <- x:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// close closes the subscriber channel, releasing a blocked send first.
+func (s *hubSubscriber[T]) close() {
+	s.doneOnce.Do(func() { close(s.done) })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.out)
+	}
+}
+
+// Hub broadcasts the elements of a source to a dynamic set of subscribers, each with its own buffer.
+// Subscribers only receive the elements emitted after they subscribed.
+type Hub[T any] struct {
+	mu          sync.Mutex
+	subscribers map[<-chan T]*hubSubscriber[T]
+	done        bool
+}
+
+// NewHub creates a Hub broadcasting the source, the source is consumed right away, with or without subscribers.
+func NewHub[T any](source <-chan T) *Hub[T] {
+	return NewHubCtx(context.Background(), source)
+}
+
+// NewHubCtx works like NewHub, closing all the subscriptions on cancellation of the context.Context.
+func NewHubCtx[T any](ctx context.Context, source <-chan T) *Hub[T] {
+	h := &Hub[T]{subscribers: make(map[<-chan T]*hubSubscriber[T])}
+	go func() {
+		defer h.shutdown()
+		for {
+			x, ok := recvCtx(ctx, source)
+			if !ok {
+				return
+			}
+			for _, s := range h.snapshot() {
+				if !s.deliver(ctx, x) {
+					h.Unsubscribe(s.out)
+				}
+			}
+		}
+	}()
+	return h
+}
+
+func (h *Hub[T]) snapshot() []*hubSubscriber[T] {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subscribers := make([]*hubSubscriber[T], 0, len(h.subscribers))
+	for _, s := range h.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	return subscribers
+}
+
+// shutdown closes all the subscriptions once the source is done.
+func (h *Hub[T]) shutdown() {
+	h.mu.Lock()
+	h.done = true
+	subscribers := h.subscribers
+	h.subscribers = make(map[<-chan T]*hubSubscriber[T])
+	h.mu.Unlock()
+	for _, s := range subscribers {
+		s.close()
+	}
+}
+
+// Subscribe returns a channel receiving the elements of the Hub, buffering up to `bufferSize` elements.
+// When the buffer is full the OverflowStrategy applies: Ignore blocks the Hub until the subscriber catches up,
+// DropHead drops the oldest buffered element, DropTail drops the new element and Error disconnects the subscriber,
+// closing its channel. Subscribing to a Hub whose source is done returns a closed channel.
+func (h *Hub[T]) Subscribe(bufferSize int, strategy OverflowStrategy) <-chan T {
+	s := &hubSubscriber[T]{
+		out:      make(chan T, max(bufferSize, 1)),
+		strategy: strategy,
+		done:     make(chan struct{}),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.done {
+		s.closed = true
+		close(s.out)
+		return s.out
+	}
+	h.subscribers[s.out] = s
+	return s.out
+}
+
+// Unsubscribe detaches the subscriber channel from the Hub and closes it.
+// The elements already buffered can still be read.
+func (h *Hub[T]) Unsubscribe(subscription <-chan T) {
+	h.mu.Lock()
+	s, ok := h.subscribers[subscription]
+	delete(h.subscribers, subscription)
+	h.mu.Unlock()
+	if ok {
+		s.close()
+	}
+}
+
+// Subscribers returns the number of subscribers of the Hub.
+func (h *Hub[T]) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
@@ -0,0 +1,268 @@
+package chanstreaming
+
+import (
+	"context"
+	"time"
+)
+
+type JoinMode int
+
+const (
+	InnerJoin     JoinMode = iota // only the matched pairs are emitted
+	LeftJoin                      // the left elements left unmatched are emitted on expiry with a nil right element
+	FullOuterJoin                 // the elements of both sides left unmatched are emitted on expiry with a nil counterpart
+)
+
+// JoinConfig defines the mode of JoinWithin and how many elements each side buffers.
+// When a side holds MaxSize elements the OverflowStrategy applies, like in WindowConfig:
+// DropHead expires the oldest element early, DropTail doesn't buffer the new element, Error panics and Ignore buffers it anyway.
+type JoinConfig struct {
+	Mode             JoinMode
+	MaxSize          int
+	OverflowStrategy OverflowStrategy
+}
+
+func (c *JoinConfig) WithMode(mode JoinMode) JoinConfig {
+	return JoinConfig{
+		Mode:             mode,
+		MaxSize:          c.MaxSize,
+		OverflowStrategy: c.OverflowStrategy,
+	}
+}
+
+func (c *JoinConfig) WithMaxSize(maxSize int) JoinConfig {
+	return JoinConfig{
+		Mode:             c.Mode,
+		MaxSize:          maxSize,
+		OverflowStrategy: DropHead,
+	}
+}
+
+func (c *JoinConfig) WithOverflowStrategy(overflowStrategy OverflowStrategy) JoinConfig {
+	return JoinConfig{
+		Mode:             c.Mode,
+		MaxSize:          c.MaxSize,
+		OverflowStrategy: overflowStrategy,
+	}
+}
+
+// joinEntry is an element buffered by a side of JoinWithin
+type joinEntry[K comparable, T any] struct {
+	key     K
+	data    T
+	ts      time.Time
+	matched bool
+}
+
+// joinSide buffers the elements of a side of JoinWithin in the order of their arrival
+type joinSide[K comparable, T any] struct {
+	byKey map[K][]*joinEntry[K, T]
+	queue []*joinEntry[K, T]
+}
+
+func newJoinSide[K comparable, T any]() *joinSide[K, T] {
+	return &joinSide[K, T]{byKey: make(map[K][]*joinEntry[K, T])}
+}
+
+func (s *joinSide[K, T]) add(e *joinEntry[K, T]) {
+	s.byKey[e.key] = append(s.byKey[e.key], e)
+	s.queue = append(s.queue, e)
+}
+
+// popOldest removes the oldest buffered element, which is also the oldest of its key.
+func (s *joinSide[K, T]) popOldest() *joinEntry[K, T] {
+	e := s.queue[0]
+	s.queue = s.queue[1:]
+	if rest := s.byKey[e.key][1:]; len(rest) > 0 {
+		s.byKey[e.key] = rest
+	} else {
+		delete(s.byKey, e.key)
+	}
+	return e
+}
+
+// nextExpiry returns when the oldest buffered element expires, reporting false if there are none.
+func (s *joinSide[K, T]) nextExpiry(within time.Duration) (time.Time, bool) {
+	if len(s.queue) == 0 {
+		return time.Time{}, false
+	}
+	return s.queue[0].ts.Add(within), true
+}
+
+// JoinWithin joins the elements of the left and right channels sharing the key extracted with `leftKey` and `rightKey`
+// and arriving within `within` of each other, emitting the result of `combine` for each matched pair.
+// Depending on the config Mode the elements left unmatched are emitted once they expire with a nil counterpart;
+// when both sources close the buffered elements expire at once.
+func JoinWithin[K comparable, A any, B any, R any](leftKey func(A) K, rightKey func(B) K, within time.Duration, combine func(key K, left *A, right *B) R, config JoinConfig) func(left <-chan A, right <-chan B) <-chan R {
+	return JoinWithinCtx[K, A, B, R](context.Background(), leftKey, rightKey, within, combine, config)
+}
+
+// JoinWithinCtx works like JoinWithin, closing the resulting channel on cancellation of the context.Context.
+func JoinWithinCtx[K comparable, A any, B any, R any](ctx context.Context, leftKey func(A) K, rightKey func(B) K, within time.Duration, combine func(key K, left *A, right *B) R, config JoinConfig) func(left <-chan A, right <-chan B) <-chan R {
+	return func(left <-chan A, right <-chan B) <-chan R {
+		out := make(chan R, 1)
+		go func() {
+			defer close(out)
+			clock := ClockFromContext(ctx)
+			lefts := newJoinSide[K, A]()
+			rights := newJoinSide[K, B]()
+
+			expireLeft := func() bool {
+				e := lefts.popOldest()
+				if e.matched || config.Mode == InnerJoin {
+					return true
+				}
+				return sendCtx(ctx, out, combine(e.key, &e.data, nil))
+			}
+			expireRight := func() bool {
+				e := rights.popOldest()
+				if e.matched || config.Mode != FullOuterJoin {
+					return true
+				}
+				return sendCtx(ctx, out, combine(e.key, nil, &e.data))
+			}
+			// expire removes the elements older than `within` from both sides, in the order of their arrival
+			expire := func(now time.Time) bool {
+				for {
+					leftAt, hasLeft := lefts.nextExpiry(within)
+					rightAt, hasRight := rights.nextExpiry(within)
+					leftDue := hasLeft && !now.Before(leftAt)
+					rightDue := hasRight && !now.Before(rightAt)
+					switch {
+					case leftDue && (!rightDue || !rightAt.Before(leftAt)):
+						if !expireLeft() {
+							return false
+						}
+					case rightDue:
+						if !expireRight() {
+							return false
+						}
+					default:
+						return true
+					}
+				}
+			}
+			// overflows applies the OverflowStrategy to a full side, reporting whether the new element is to be buffered
+			overflows := func(size int, dropHead func() bool) (buffer bool, ok bool) {
+				if config.MaxSize <= 0 || size < config.MaxSize {
+					return true, true
+				}
+				switch config.OverflowStrategy {
+				case Ignore:
+					return true, true
+				case DropTail:
+					return false, true
+				case DropHead:
+					return true, dropHead()
+				case Error:
+					fallthrough
+				default:
+					panic(NewWindowOverflowError())
+				}
+			}
+
+			var timer Timer
+			var timerC <-chan time.Time
+			var timerAt time.Time
+			schedule := func() {
+				at, ok := lefts.nextExpiry(within)
+				if rightAt, hasRight := rights.nextExpiry(within); hasRight && (!ok || rightAt.Before(at)) {
+					at, ok = rightAt, true
+				}
+				if timer != nil && ok && at.Equal(timerAt) {
+					return
+				}
+				if timer != nil {
+					timer.Stop()
+					timer, timerC = nil, nil
+				}
+				if ok {
+					timer, timerAt = clock.NewTimer(at.Sub(clock.Now())), at
+					timerC = timer.C()
+				}
+			}
+			defer func() {
+				if timer != nil {
+					timer.Stop()
+				}
+			}()
+
+			for left != nil || right != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-timerC:
+					timer, timerC = nil, nil
+					if !expire(clock.Now()) {
+						return
+					}
+				case a, ok := <-left:
+					if !ok {
+						left = nil
+						continue
+					}
+					now := clock.Now()
+					if !expire(now) {
+						return
+					}
+					e := &joinEntry[K, A]{key: leftKey(a), data: a, ts: now}
+					for _, match := range rights.byKey[e.key] {
+						e.matched, match.matched = true, true
+						if !sendCtx(ctx, out, combine(e.key, &e.data, &match.data)) {
+							return
+						}
+					}
+					buffer, ok := overflows(len(lefts.queue), expireLeft)
+					if !ok {
+						return
+					}
+					if buffer {
+						lefts.add(e)
+					} else if !e.matched && config.Mode != InnerJoin && !sendCtx(ctx, out, combine(e.key, &e.data, nil)) {
+						return
+					}
+				case b, ok := <-right:
+					if !ok {
+						right = nil
+						continue
+					}
+					now := clock.Now()
+					if !expire(now) {
+						return
+					}
+					e := &joinEntry[K, B]{key: rightKey(b), data: b, ts: now}
+					for _, match := range lefts.byKey[e.key] {
+						e.matched, match.matched = true, true
+						if !sendCtx(ctx, out, combine(e.key, &match.data, &e.data)) {
+							return
+						}
+					}
+					buffer, ok := overflows(len(rights.queue), expireRight)
+					if !ok {
+						return
+					}
+					if buffer {
+						rights.add(e)
+					} else if !e.matched && config.Mode == FullOuterJoin && !sendCtx(ctx, out, combine(e.key, nil, &e.data)) {
+						return
+					}
+				}
+				schedule()
+			}
+
+			// both sources are complete, so is every window
+			for len(lefts.queue) > 0 || len(rights.queue) > 0 {
+				leftAt, hasLeft := lefts.nextExpiry(within)
+				rightAt, hasRight := rights.nextExpiry(within)
+				if hasLeft && (!hasRight || !rightAt.Before(leftAt)) {
+					if !expireLeft() {
+						return
+					}
+				} else if !expireRight() {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
@@ -0,0 +1,250 @@
+package chanstreaming
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// KeyedState is the state of a key held by the keyed stateful operators.
+type KeyedState[K comparable, S any] struct {
+	Key   K
+	State S
+}
+
+// KeyedStateConfig defines how long the keyed stateful operators hold the state of a key.
+type KeyedStateConfig struct {
+	// TTL evicts the state of a key not updated for this long, zero means never
+	TTL time.Duration
+	// MaxKeys evicts the least recently updated state to hold at most this many keys, zero means unbounded
+	MaxKeys int
+	// EmitFinal makes ScanByKey emit the states still held when the source closes
+	EmitFinal bool
+}
+
+func (c *KeyedStateConfig) WithTTL(ttl time.Duration) KeyedStateConfig {
+	return KeyedStateConfig{
+		TTL:       ttl,
+		MaxKeys:   c.MaxKeys,
+		EmitFinal: c.EmitFinal,
+	}
+}
+
+func (c *KeyedStateConfig) WithMaxKeys(maxKeys int) KeyedStateConfig {
+	return KeyedStateConfig{
+		TTL:       c.TTL,
+		MaxKeys:   maxKeys,
+		EmitFinal: c.EmitFinal,
+	}
+}
+
+func (c *KeyedStateConfig) WithEmitFinal(emitFinal bool) KeyedStateConfig {
+	return KeyedStateConfig{
+		TTL:       c.TTL,
+		MaxKeys:   c.MaxKeys,
+		EmitFinal: emitFinal,
+	}
+}
+
+// keyedStateEntry is the state of a key along with its last update
+type keyedStateEntry[S any] struct {
+	state S
+	last  time.Time
+	// seq orders the entries by their last update
+	seq uint64
+}
+
+// keyedStateCtx maintains a state per key with `fn`, `found` being false for the first element of a key.
+// With `fold` set the states are emitted when evicted and on completion, otherwise on each update.
+// The states are restored from and checkpointed to the `checkpoint` Store, if any.
+func keyedStateCtx[K comparable, T any, S any](ctx context.Context, keyFn func(T) K, fn func(state S, x T, found bool) S, config KeyedStateConfig, checkpoint CheckpointConfig, fold bool) func(in <-chan T) <-chan KeyedState[K, S] {
+	return func(in <-chan T) <-chan KeyedState[K, S] {
+		out := make(chan KeyedState[K, S], 1)
+		go func() {
+			defer close(out)
+			clock := ClockFromContext(ctx)
+			states := make(map[K]*keyedStateEntry[S])
+			var seq uint64
+			for _, restored := range restoreCheckpoint[[]KeyedState[K, S]](checkpoint, nil) {
+				seq++
+				states[restored.Key] = &keyedStateEntry[S]{state: restored.State, last: clock.Now(), seq: seq}
+			}
+			// snapshot lists the states in the order of their updates
+			snapshot := func() []KeyedState[K, S] {
+				result := make([]KeyedState[K, S], 0, len(states))
+				for key, e := range states {
+					result = append(result, KeyedState[K, S]{Key: key, State: e.state})
+				}
+				sort.Slice(result, func(i, j int) bool {
+					return states[result[i].Key].seq < states[result[j].Key].seq
+				})
+				return result
+			}
+			checkpoints, tick := newCheckpointer(ctx, checkpoint)
+			defer checkpoints.stop()
+
+			// evict removes the states for which `evicted` holds in the order of their updates, emitting them when folding
+			evict := func(evicted func(e *keyedStateEntry[S]) bool, emit bool) bool {
+				keys := []K{}
+				for key, e := range states {
+					if evicted(e) {
+						keys = append(keys, key)
+					}
+				}
+				sort.Slice(keys, func(i, j int) bool {
+					return states[keys[i]].seq < states[keys[j]].seq
+				})
+				for _, key := range keys {
+					// a state that failed to be emitted is kept for the checkpoint
+					if emit && !sendCtx(ctx, out, KeyedState[K, S]{Key: key, State: states[key].state}) {
+						return false
+					}
+					delete(states, key)
+				}
+				return true
+			}
+
+			// the timer wakes up at the earliest expiry at the time it was set, states updated since then are checked again
+			var timer Timer
+			var timerC <-chan time.Time
+			schedule := func() {
+				if timer != nil {
+					timer.Stop()
+					timer, timerC = nil, nil
+				}
+				if config.TTL <= 0 || len(states) == 0 {
+					return
+				}
+				var next time.Time
+				first := true
+				for _, e := range states {
+					if first || e.last.Before(next) {
+						next, first = e.last, false
+					}
+				}
+				timer = clock.NewTimer(next.Add(config.TTL).Sub(clock.Now()))
+				timerC = timer.C()
+			}
+			defer func() {
+				if timer != nil {
+					timer.Stop()
+				}
+			}()
+			if len(states) > 0 {
+				schedule()
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					saveCheckpoint(checkpoint, snapshot())
+					return
+				case <-tick:
+					saveCheckpoint(checkpoint, snapshot())
+				case <-timerC:
+					now := clock.Now()
+					if !evict(func(e *keyedStateEntry[S]) bool { return now.Sub(e.last) >= config.TTL }, fold) {
+						return
+					}
+					schedule()
+				case x, ok := <-in:
+					if !ok {
+						// the emitted final states are dropped from the checkpoint, a restart does not emit them again
+						if fold || config.EmitFinal {
+							evict(func(*keyedStateEntry[S]) bool { return true }, true)
+						}
+						saveCheckpoint(checkpoint, snapshot())
+						return
+					}
+					now := clock.Now()
+					key := keyFn(x)
+					e, found := states[key]
+					if found && config.TTL > 0 && now.Sub(e.last) >= config.TTL {
+						// the state is expired, even though the timer has not been handled yet
+						if !evict(func(candidate *keyedStateEntry[S]) bool { return candidate == e }, fold) {
+							return
+						}
+						found = false
+					}
+					if !found {
+						if config.MaxKeys > 0 && len(states) >= config.MaxKeys {
+							var oldest *keyedStateEntry[S]
+							for _, candidate := range states {
+								if oldest == nil || candidate.seq < oldest.seq {
+									oldest = candidate
+								}
+							}
+							if !evict(func(candidate *keyedStateEntry[S]) bool { return candidate == oldest }, fold) {
+								return
+							}
+						}
+						e = &keyedStateEntry[S]{}
+						states[key] = e
+					}
+					seq++
+					e.state = fn(e.state, x, found)
+					e.last = now
+					e.seq = seq
+					if timer == nil {
+						schedule()
+					}
+					if checkpoints.element() {
+						saveCheckpoint(checkpoint, snapshot())
+					}
+					if !fold && !sendCtx(ctx, out, KeyedState[K, S]{Key: key, State: e.state}) {
+						saveCheckpoint(checkpoint, snapshot())
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// foldFromInitial adapts the `fn` of the keyed operators to start each key from `initialState`.
+func foldFromInitial[TIn any, TState any](fn func(TState, TIn) TState, initialState TState) func(state TState, x TIn, found bool) TState {
+	return func(state TState, x TIn, found bool) TState {
+		if !found {
+			state = initialState
+		}
+		return fn(state, x)
+	}
+}
+
+// ScanByKey works like Scan, holding a separate state per key extracted with `keyFn` and emitting the updated KeyedState per element.
+// A key evicted by the config limits starts over from `initialState`.
+func ScanByKey[K comparable, TIn any, TState any](keyFn func(TIn) K, fn func(TState, TIn) TState, initialState TState, config KeyedStateConfig) func(in <-chan TIn) <-chan KeyedState[K, TState] {
+	return ScanByKeyCtx[K, TIn, TState](context.Background(), keyFn, fn, initialState, config)
+}
+
+// ScanByKeyCtx works like ScanByKey, closing the resulting channel on cancellation of the context.Context.
+func ScanByKeyCtx[K comparable, TIn any, TState any](ctx context.Context, keyFn func(TIn) K, fn func(TState, TIn) TState, initialState TState, config KeyedStateConfig) func(in <-chan TIn) <-chan KeyedState[K, TState] {
+	return keyedStateCtx[K, TIn, TState](ctx, keyFn, foldFromInitial(fn, initialState), config, CheckpointConfig{}, false)
+}
+
+// FoldByKey works like Fold, holding a separate state per key extracted with `keyFn`.
+// The KeyedState of a key is emitted when it is evicted by the config limits and when the source closes.
+func FoldByKey[K comparable, TIn any, TState any](keyFn func(TIn) K, fn func(TState, TIn) TState, initialState TState, config KeyedStateConfig) func(in <-chan TIn) <-chan KeyedState[K, TState] {
+	return FoldByKeyCtx[K, TIn, TState](context.Background(), keyFn, fn, initialState, config)
+}
+
+// FoldByKeyCtx works like FoldByKey, closing the resulting channel on cancellation of the context.Context.
+func FoldByKeyCtx[K comparable, TIn any, TState any](ctx context.Context, keyFn func(TIn) K, fn func(TState, TIn) TState, initialState TState, config KeyedStateConfig) func(in <-chan TIn) <-chan KeyedState[K, TState] {
+	return keyedStateCtx[K, TIn, TState](ctx, keyFn, foldFromInitial(fn, initialState), config, CheckpointConfig{}, true)
+}
+
+// ReduceByKey works like FoldByKey, starting the state of each key from its first element.
+func ReduceByKey[K comparable, T any](keyFn func(T) K, fn func(T, T) T, config KeyedStateConfig) func(in <-chan T) <-chan KeyedState[K, T] {
+	return ReduceByKeyCtx[K, T](context.Background(), keyFn, fn, config)
+}
+
+// ReduceByKeyCtx works like ReduceByKey, closing the resulting channel on cancellation of the context.Context.
+func ReduceByKeyCtx[K comparable, T any](ctx context.Context, keyFn func(T) K, fn func(T, T) T, config KeyedStateConfig) func(in <-chan T) <-chan KeyedState[K, T] {
+	return keyedStateCtx[K, T, T](ctx, keyFn, func(state T, x T, found bool) T {
+		if !found {
+			return x
+		}
+		return fn(state, x)
+	}, config, CheckpointConfig{}, true)
+}
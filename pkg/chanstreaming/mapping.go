@@ -1,13 +1,26 @@
 package chanstreaming
 
+import "context"
+
 // Mapped applies a transformation function to each element in the source channel without any parallelism.
 func Mapped[T any, R any](fn func(T) R) func(in <-chan T) <-chan R {
+	return MappedCtx[T, R](context.Background(), fn)
+}
+
+// MappedCtx works like Mapped, closing the resulting channel on cancellation of the context.Context.
+func MappedCtx[T any, R any](ctx context.Context, fn func(T) R) func(in <-chan T) <-chan R {
 	return func(in <-chan T) <-chan R {
 		out := make(chan R, 1)
 		go func() {
 			defer close(out)
-			for x := range in {
-				out <- fn(x)
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if !sendCtx(ctx, out, fn(x)) {
+					return
+				}
 			}
 		}()
 		return out
@@ -17,7 +30,12 @@ func Mapped[T any, R any](fn func(T) R) func(in <-chan T) <-chan R {
 // Apply applies a function to each element in the source channel and returns the original element.
 // This is useful for side effect with no meaningful results like logging.
 func Apply[T any](fn func(T)) func(in <-chan T) <-chan T {
-	return Mapped[T, T](func(x T) T {
+	return ApplyCtx[T](context.Background(), fn)
+}
+
+// ApplyCtx works like Apply, closing the resulting channel on cancellation of the context.Context.
+func ApplyCtx[T any](ctx context.Context, fn func(T)) func(in <-chan T) <-chan T {
+	return MappedCtx[T, T](ctx, func(x T) T {
 		fn(x)
 		return x
 	})
@@ -25,13 +43,22 @@ func Apply[T any](fn func(T)) func(in <-chan T) <-chan T {
 
 // Filter filters the source channel based on the `predicate` function.
 func Filter[T any](predicate func(el T) bool) func(in <-chan T) <-chan T {
+	return FilterCtx[T](context.Background(), predicate)
+}
+
+// FilterCtx works like Filter, closing the resulting channel on cancellation of the context.Context.
+func FilterCtx[T any](ctx context.Context, predicate func(el T) bool) func(in <-chan T) <-chan T {
 	return func(in <-chan T) <-chan T {
 		out := make(chan T, 1)
 		go func() {
 			defer close(out)
-			for x := range in {
-				if predicate(x) {
-					out <- x
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if predicate(x) && !sendCtx(ctx, out, x) {
+					return
 				}
 			}
 		}()
@@ -41,6 +68,12 @@ func Filter[T any](predicate func(el T) bool) func(in <-chan T) <-chan T {
 
 // Map applies a transformation function to each element in parallel, preserving order
 func Map[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan R {
+	return MapCtx[T, R](context.Background(), fn, maxWorkers)
+}
+
+// MapCtx works like Map, closing the resulting channel on cancellation of the context.Context.
+// Calls of `fn` that are already running are not interrupted, their results are discarded.
+func MapCtx[T any, R any](ctx context.Context, fn func(T) R, maxWorkers int) func(in <-chan T) <-chan R {
 	return func(in <-chan T) <-chan R {
 		// each element of out represents optional 'awaitable' expressed with a size-1 channel.
 		// the out itself is a channel limiting the number of concurrent workers
@@ -49,10 +82,15 @@ func Map[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan R
 		// launch input processor
 		go func() {
 			defer close(tasksChannel)
-			for i := range in {
-				item := i
+			for {
+				item, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
 				resultChan := make(chan R, 1)
-				tasksChannel <- resultChan
+				if !sendCtx(ctx, tasksChannel, resultChan) {
+					return
+				}
 				go func() {
 					result := fn(item)
 					resultChan <- result
@@ -66,8 +104,13 @@ func Map[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan R
 		go func() {
 			defer close(outChannel)
 			for resultChan := range tasksChannel {
-				result := <-resultChan
-				outChannel <- result
+				result, ok := recvCtx(ctx, resultChan)
+				if !ok {
+					return
+				}
+				if !sendCtx(ctx, outChannel, result) {
+					return
+				}
 			}
 		}()
 
@@ -77,6 +120,12 @@ func Map[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan R
 
 // MapUnordered applies a transformation function in parallel without preserving order
 func MapUnordered[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan R {
+	return MapUnorderedCtx[T, R](context.Background(), fn, maxWorkers)
+}
+
+// MapUnorderedCtx works like MapUnordered, closing the resulting channel and stopping the workers
+// on cancellation of the context.Context.
+func MapUnorderedCtx[T any, R any](ctx context.Context, fn func(T) R, maxWorkers int) func(in <-chan T) <-chan R {
 	return func(in <-chan T) <-chan R {
 		out := make(chan R, maxWorkers)
 		// the pure form of sync.WaitGroup
@@ -85,11 +134,18 @@ func MapUnordered[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T)
 		// launch maxWorkers
 		for i := 0; i < maxWorkers; i++ {
 			go func() {
-				for item := range in {
-					result := fn(item)
-					out <- result
+				defer func() {
+					doneQueue <- struct{}{}
+				}()
+				for {
+					item, ok := recvCtx(ctx, in)
+					if !ok {
+						return
+					}
+					if !sendCtx(ctx, out, fn(item)) {
+						return
+					}
 				}
-				doneQueue <- struct{}{}
 			}()
 		}
 
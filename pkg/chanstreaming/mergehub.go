@@ -0,0 +1,144 @@
+package chanstreaming
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrMergeHubSealed is returned when attaching a source to a sealed MergeHub.
+var ErrMergeHubSealed = errors.New("merge hub is sealed")
+
+// MergeHub merges a dynamic set of sources attached and detached while its output runs.
+// The output is closed once the MergeHub is sealed and all the attached sources are done.
+type MergeHub[T any] struct {
+	ctx      context.Context
+	out      chan T
+	mu       sync.Mutex
+	sealed   bool
+	sources  map[<-chan T]*mergeHubSource
+	wg       sync.WaitGroup
+	seal     chan struct{}
+	sealOnce sync.Once
+}
+
+// mergeHubSource is a source attached to a MergeHub
+type mergeHubSource struct {
+	detach chan struct{}
+	// detached is guarded by the mutex of the MergeHub
+	detached bool
+	// stopped is closed once the MergeHub no longer reads the source
+	stopped chan struct{}
+}
+
+// NewMergeHub creates an empty MergeHub.
+func NewMergeHub[T any]() *MergeHub[T] {
+	return NewMergeHubCtx[T](context.Background())
+}
+
+// NewMergeHubCtx works like NewMergeHub, sealing the MergeHub and closing its output on cancellation of the context.Context.
+func NewMergeHubCtx[T any](ctx context.Context) *MergeHub[T] {
+	h := &MergeHub[T]{
+		ctx:     ctx,
+		out:     make(chan T, 1),
+		sources: make(map[<-chan T]*mergeHubSource),
+		seal:    make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-h.seal:
+		case <-ctx.Done():
+		}
+		h.mu.Lock()
+		h.sealed = true
+		h.mu.Unlock()
+		h.wg.Wait()
+		close(h.out)
+	}()
+	return h
+}
+
+// Out returns the merged output of the MergeHub.
+func (h *MergeHub[T]) Out() <-chan T {
+	return h.out
+}
+
+// Attach starts merging the source into the output, until the source is closed or detached.
+// Attaching a source that is already attached does nothing, a source being detached is read again
+// once the MergeHub is done with its previous attachment. Returns ErrMergeHubSealed if the MergeHub is sealed.
+func (h *MergeHub[T]) Attach(source <-chan T) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.sealed {
+		return ErrMergeHubSealed
+	}
+	previous, ok := h.sources[source]
+	if ok && !previous.detached {
+		return nil
+	}
+	attached := &mergeHubSource{detach: make(chan struct{}), stopped: make(chan struct{})}
+	h.sources[source] = attached
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer func() {
+			h.mu.Lock()
+			if h.sources[source] == attached {
+				delete(h.sources, source)
+			}
+			h.mu.Unlock()
+			close(attached.stopped)
+		}()
+		if previous != nil {
+			// the previous attachment may still deliver an element it took, keep the source in order
+			select {
+			case <-previous.stopped:
+			case <-h.ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case <-h.ctx.Done():
+				return
+			case <-attached.detach:
+				return
+			case x, ok := <-source:
+				if !ok {
+					return
+				}
+				// the element already taken from the source is delivered even if detached meanwhile
+				if !sendCtx(h.ctx, h.out, x) {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Detach stops merging the source, leaving the rest of it to the caller.
+// The resulting channel is closed once the MergeHub no longer reads the source, which may be one element later.
+func (h *MergeHub[T]) Detach(source <-chan T) <-chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	attached, ok := h.sources[source]
+	if !ok {
+		stopped := make(chan struct{})
+		close(stopped)
+		return stopped
+	}
+	if !attached.detached {
+		attached.detached = true
+		close(attached.detach)
+	}
+	return attached.stopped
+}
+
+// Seal stops accepting new sources, the output is closed once all the attached sources are done.
+func (h *MergeHub[T]) Seal() {
+	h.mu.Lock()
+	h.sealed = true
+	h.mu.Unlock()
+	h.sealOnce.Do(func() { close(h.seal) })
+}
@@ -0,0 +1,157 @@
+package chanstreaming
+
+import (
+	"container/heap"
+	"context"
+	"reflect"
+	"time"
+)
+
+// sortedHead is the next element of a source of MergeSorted
+type sortedHead[T any] struct {
+	data   T
+	source int
+}
+
+// sortedHeads is the heap of the source heads of MergeSorted, ties are broken by the source index
+type sortedHeads[T any] struct {
+	heads []sortedHead[T]
+	less  func(a, b T) bool
+}
+
+func (h *sortedHeads[T]) Len() int {
+	return len(h.heads)
+}
+
+func (h *sortedHeads[T]) Less(i, j int) bool {
+	if h.less(h.heads[i].data, h.heads[j].data) {
+		return true
+	}
+	if h.less(h.heads[j].data, h.heads[i].data) {
+		return false
+	}
+	return h.heads[i].source < h.heads[j].source
+}
+
+func (h *sortedHeads[T]) Swap(i, j int) {
+	h.heads[i], h.heads[j] = h.heads[j], h.heads[i]
+}
+
+func (h *sortedHeads[T]) Push(x any) {
+	h.heads = append(h.heads, x.(sortedHead[T]))
+}
+
+func (h *sortedHeads[T]) Pop() any {
+	last := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return last
+}
+
+// MergeSorted merges the sources, each sorted by `less`, into a single channel sorted by `less`.
+// It reads the next element of every source before emitting, so a source that goes quiet stalls the merge,
+// see MergeSortedTimeout for the variant that moves on without it.
+func MergeSorted[T any](less func(a, b T) bool, sources ...<-chan T) <-chan T {
+	return MergeSortedCtx(context.Background(), less, sources...)
+}
+
+// MergeSortedCtx works like MergeSorted, closing the resulting channel on cancellation of the context.Context.
+func MergeSortedCtx[T any](ctx context.Context, less func(a, b T) bool, sources ...<-chan T) <-chan T {
+	return MergeSortedTimeoutCtx(ctx, less, 0, sources...)
+}
+
+// MergeSortedTimeout works like MergeSorted, moving on without a source that has no element ready for `quietTimeout`.
+// The elements of such a source are merged again once it emits, the ones below the elements already emitted are emitted
+// as soon as they arrive, so the global order only holds while no source goes quiet. Zero `quietTimeout` waits forever.
+func MergeSortedTimeout[T any](less func(a, b T) bool, quietTimeout time.Duration, sources ...<-chan T) <-chan T {
+	return MergeSortedTimeoutCtx(context.Background(), less, quietTimeout, sources...)
+}
+
+// MergeSortedTimeoutCtx works like MergeSortedTimeout, closing the resulting channel on cancellation of the context.Context.
+func MergeSortedTimeoutCtx[T any](ctx context.Context, less func(a, b T) bool, quietTimeout time.Duration, sources ...<-chan T) <-chan T {
+	out := make(chan T, 1)
+	go func() {
+		defer close(out)
+		clock := ClockFromContext(ctx)
+		heads := &sortedHeads[T]{less: less}
+		// quiet sources timed out, they are polled before each element is emitted
+		quiet := make(map[int]bool)
+
+		// fetch reads the next element of the source, giving up after `quietTimeout`
+		fetch := func(i int) bool {
+			var timeout <-chan time.Time
+			if quietTimeout > 0 {
+				timer := clock.NewTimer(quietTimeout)
+				defer timer.Stop()
+				timeout = timer.C()
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case x, ok := <-sources[i]:
+				if ok {
+					heap.Push(heads, sortedHead[T]{data: x, source: i})
+				}
+			case <-timeout:
+				quiet[i] = true
+			}
+			return true
+		}
+		// pollQuiet merges the quiet sources that have an element ready, waiting for any of them when `wait` is set
+		pollQuiet := func(wait bool) bool {
+			if len(quiet) == 0 {
+				return true
+			}
+			cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+			indexes := []int{-1}
+			for i := range quiet {
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sources[i])})
+				indexes = append(indexes, i)
+			}
+			if !wait {
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+			}
+			for len(cases) > 1 {
+				chosen, value, ok := reflect.Select(cases)
+				if chosen == 0 {
+					return false
+				}
+				if cases[chosen].Dir == reflect.SelectDefault {
+					return true
+				}
+				i := indexes[chosen]
+				delete(quiet, i)
+				if ok {
+					heap.Push(heads, sortedHead[T]{data: value.Interface().(T), source: i})
+				}
+				cases = append(cases[:chosen], cases[chosen+1:]...)
+				indexes = append(indexes[:chosen], indexes[chosen+1:]...)
+				if wait && ok {
+					return true
+				}
+			}
+			return true
+		}
+
+		for i := range sources {
+			if !fetch(i) {
+				return
+			}
+		}
+		for heads.Len() > 0 || len(quiet) > 0 {
+			if !pollQuiet(heads.Len() == 0) {
+				return
+			}
+			if heads.Len() == 0 {
+				continue
+			}
+			head := heap.Pop(heads).(sortedHead[T])
+			if !sendCtx(ctx, out, head.data) {
+				return
+			}
+			if !fetch(head.source) {
+				return
+			}
+		}
+	}()
+	return out
+}
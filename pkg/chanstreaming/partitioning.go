@@ -1,9 +1,17 @@
 package chanstreaming
 
+import "context"
+
 // Merge takes multiple input channels and combines them into a single output channel.
 // It launches `len(sources)` worker goroutines to consume from the sources concurrently
 // until all of them have been closed.
 func Merge[T any](sources []<-chan T) <-chan T {
+	return MergeCtx(context.Background(), sources)
+}
+
+// MergeCtx works like Merge, closing the resulting channel and stopping the workers
+// on cancellation of the context.Context.
+func MergeCtx[T any](ctx context.Context, sources []<-chan T) <-chan T {
 	out := make(chan T, 1)
 	done := make(chan struct{}, 1)
 	srcCount := len(sources)
@@ -13,8 +21,14 @@ func Merge[T any](sources []<-chan T) <-chan T {
 				defer func() {
 					done <- struct{}{}
 				}()
-				for v := range src {
-					out <- v
+				for {
+					v, ok := recvCtx(ctx, src)
+					if !ok {
+						return
+					}
+					if !sendCtx(ctx, out, v) {
+						return
+					}
 				}
 			}(source) // Launch a goroutine for each source, passing source explicitly
 		}
@@ -33,7 +47,13 @@ func Merge[T any](sources []<-chan T) <-chan T {
 
 // Partition splits an input channel into `maxPartitions` separate output channels
 // based on a `partitioner` function that returns a partition number.
+// Every partition must be consumed or passed to Drain, a partition left unread blocks all the others.
 func Partition[T any](maxPartitions int, partitioner func(T) int) func(in <-chan T) []<-chan T {
+	return PartitionCtx[T](context.Background(), maxPartitions, partitioner)
+}
+
+// PartitionCtx works like Partition, closing all partitions on cancellation of the context.Context.
+func PartitionCtx[T any](ctx context.Context, maxPartitions int, partitioner func(T) int) func(in <-chan T) []<-chan T {
 	return func(in <-chan T) []<-chan T {
 		partitions := make([]chan T, maxPartitions)
 		// Create partition channels with size 1
@@ -50,7 +70,11 @@ func Partition[T any](maxPartitions int, partitioner func(T) int) func(in <-chan
 				}
 			}()
 
-			for item := range in {
+			for {
+				item, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
 				idx := partitioner(item)
 				if idx < 0 {
 					idx = -idx // Convert negative to positive
@@ -58,7 +82,9 @@ func Partition[T any](maxPartitions int, partitioner func(T) int) func(in <-chan
 
 				idx = idx % maxPartitions
 
-				partitions[idx] <- item
+				if !sendCtx(ctx, partitions[idx], item) {
+					return
+				}
 			}
 		}()
 
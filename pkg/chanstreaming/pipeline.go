@@ -0,0 +1,286 @@
+package chanstreaming
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Pipe2 composes two stages into a single stage, feeding the output of `f1` into `f2`.
+func Pipe2[A any, B any, C any](
+	f1 func(in <-chan A) <-chan B,
+	f2 func(in <-chan B) <-chan C,
+) func(in <-chan A) <-chan C {
+	return func(in <-chan A) <-chan C {
+		return f2(f1(in))
+	}
+}
+
+// Pipe3 composes three stages into a single stage.
+func Pipe3[A any, B any, C any, D any](
+	f1 func(in <-chan A) <-chan B,
+	f2 func(in <-chan B) <-chan C,
+	f3 func(in <-chan C) <-chan D,
+) func(in <-chan A) <-chan D {
+	return Pipe2(Pipe2(f1, f2), f3)
+}
+
+// Pipe4 composes four stages into a single stage.
+func Pipe4[A any, B any, C any, D any, E any](
+	f1 func(in <-chan A) <-chan B,
+	f2 func(in <-chan B) <-chan C,
+	f3 func(in <-chan C) <-chan D,
+	f4 func(in <-chan D) <-chan E,
+) func(in <-chan A) <-chan E {
+	return Pipe2(Pipe3(f1, f2, f3), f4)
+}
+
+// Pipe5 composes five stages into a single stage.
+func Pipe5[A any, B any, C any, D any, E any, F any](
+	f1 func(in <-chan A) <-chan B,
+	f2 func(in <-chan B) <-chan C,
+	f3 func(in <-chan C) <-chan D,
+	f4 func(in <-chan D) <-chan E,
+	f5 func(in <-chan E) <-chan F,
+) func(in <-chan A) <-chan F {
+	return Pipe2(Pipe4(f1, f2, f3, f4), f5)
+}
+
+// Pipe6 composes six stages into a single stage.
+func Pipe6[A any, B any, C any, D any, E any, F any, G any](
+	f1 func(in <-chan A) <-chan B,
+	f2 func(in <-chan B) <-chan C,
+	f3 func(in <-chan C) <-chan D,
+	f4 func(in <-chan D) <-chan E,
+	f5 func(in <-chan E) <-chan F,
+	f6 func(in <-chan F) <-chan G,
+) func(in <-chan A) <-chan G {
+	return Pipe2(Pipe5(f1, f2, f3, f4, f5), f6)
+}
+
+// PipelineError reports a fatal failure of a named pipeline stage.
+type PipelineError struct {
+	Stage string
+	Err   error
+}
+
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("pipeline stage %q: %v", e.Stage, e.Err)
+}
+
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// pipelineStage is a named stage of the Pipeline
+type pipelineStage struct {
+	name string
+	fn   reflect.Value
+}
+
+// Pipeline is a runtime-checked builder for chains of `func(in <-chan T) <-chan R` stages.
+// Each stage is checked against the output of the previous one when added,
+// the first mismatch is remembered and reported by Flow.Run.
+// Use PipeN helpers instead when the whole chain is known at compile time.
+type Pipeline struct {
+	source func(ctx context.Context) reflect.Value
+	out    reflect.Type
+	stages []pipelineStage
+	err    error
+}
+
+// NewPipeline starts a Pipeline from the `source` channel.
+// On cancellation the Pipeline stops reading the source, the rest of it is left to its producer,
+// which is expected to stop on the same context.Context.
+func NewPipeline[T any](source <-chan T) *Pipeline {
+	return &Pipeline{
+		source: func(ctx context.Context) reflect.Value {
+			return reflect.ValueOf(ViaKillSwitch[T](ctx.Done())(source))
+		},
+		out: reflect.TypeOf(source),
+	}
+}
+
+// Via appends the stage named `name` to the Pipeline.
+// The `stage` must be a `func(in <-chan T) <-chan R` with `T` matching the output of the previous stage.
+func (p *Pipeline) Via(name string, stage any) *Pipeline {
+	if p.err != nil {
+		return p
+	}
+	fnType := reflect.TypeOf(stage)
+	if fnType == nil || fnType.Kind() != reflect.Func || fnType.NumIn() != 1 || fnType.NumOut() != 1 ||
+		!isRecvChan(fnType.In(0)) || !isRecvChan(fnType.Out(0)) {
+		p.err = &PipelineError{Stage: name, Err: fmt.Errorf("expected func(in <-chan T) <-chan R, got %v", fnType)}
+		return p
+	}
+	if !p.out.AssignableTo(fnType.In(0)) {
+		p.err = &PipelineError{Stage: name, Err: fmt.Errorf("expected input %v, got %v", fnType.In(0), p.out)}
+		return p
+	}
+	p.stages = append(p.stages, pipelineStage{name: name, fn: reflect.ValueOf(stage)})
+	p.out = fnType.Out(0)
+	return p
+}
+
+// Then appends an unnamed stage to the Pipeline, naming it after its position.
+func (p *Pipeline) Then(stage any) *Pipeline {
+	return p.Via(fmt.Sprintf("stage-%d", len(p.stages)+1), stage)
+}
+
+// Err returns the first error encountered while building the Pipeline.
+func (p *Pipeline) Err() error {
+	return p.err
+}
+
+// wire connects the source with all the stages, returning the output of the last stage.
+// The output of each stage is watched for error Results, see failureWatch.watch.
+func (p *Pipeline) wire(ctx context.Context, w *failureWatch) (out reflect.Value, err error) {
+	out = w.watch(p.source(ctx), "source")
+	for _, stage := range p.stages {
+		next, err := stage.call(out)
+		if err != nil {
+			// the stages wired so far are handed back to be drained
+			return out, err
+		}
+		out = w.watch(next, stage.name)
+	}
+	return out, nil
+}
+
+func (s pipelineStage) call(in reflect.Value) (out reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PipelineError{Stage: s.name, Err: panicToError(r)}
+		}
+	}()
+	return s.fn.Call([]reflect.Value{in})[0], nil
+}
+
+// failure is implemented by Result, letting the Pipeline spot the error Results reaching the sink
+type failure interface {
+	failure() error
+}
+
+var failureType = reflect.TypeFor[failure]()
+
+// failureWatch keeps the first fatal error of a Flow run
+type failureWatch struct {
+	once   sync.Once
+	err    error
+	cutOff context.CancelFunc
+	// done is closed once the sink returns, the watchers then drain what is left of the stages
+	done chan struct{}
+}
+
+func (w *failureWatch) set(err error) bool {
+	set := false
+	w.once.Do(func() {
+		w.err = err
+		set = true
+	})
+	return set
+}
+
+// watch forwards the output of the stage named `stage`, recording the first error Result it emits as fatal
+// and cutting off the source. The error Results emitted by the earlier stages are forwarded by them first,
+// so the fatal error names the stage it originated from. All the elements are still forwarded,
+// the error Results included, until the sink returns.
+func (w *failureWatch) watch(out reflect.Value, stage string) reflect.Value {
+	if !out.Type().Elem().Implements(failureType) {
+		return out
+	}
+	forwarded := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, out.Type().Elem()), 1)
+	go func() {
+		defer forwarded.Close()
+		for {
+			x, ok := out.Recv()
+			if !ok {
+				return
+			}
+			if err := x.Interface().(failure).failure(); err != nil && w.set(&PipelineError{Stage: stage, Err: err}) {
+				w.cutOff()
+			}
+			chosen, _, _ := reflect.Select([]reflect.SelectCase{
+				{Dir: reflect.SelectSend, Chan: forwarded, Send: x},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.done)},
+			})
+			if chosen == 1 {
+				// the sink is gone, the source is cut off by then
+				drainValue(out)
+				return
+			}
+		}
+	}()
+	return forwarded
+}
+
+// Flow is a Pipeline terminated with a sink producing `R`.
+type Flow[R any] struct {
+	pipeline *Pipeline
+	name     string
+	sink     func(in reflect.Value) R
+	err      error
+}
+
+// To terminates the Pipeline with the `sink` named `name`, like ToSlice or Fold followed by a read.
+func To[T any, R any](p *Pipeline, name string, sink func(in <-chan T) R) *Flow[R] {
+	inType := reflect.TypeFor[<-chan T]()
+	flow := &Flow[R]{
+		pipeline: p,
+		name:     name,
+		sink: func(in reflect.Value) R {
+			return sink(in.Convert(inType).Interface().(<-chan T))
+		},
+		err: p.err,
+	}
+	if flow.err == nil && !p.out.AssignableTo(inType) {
+		flow.err = &PipelineError{Stage: name, Err: fmt.Errorf("expected input %v, got %v", inType, p.out)}
+	}
+	return flow
+}
+
+// Run wires the Pipeline and blocks until the sink returns.
+// The source is cut off on cancellation of the context.Context, letting the stages drain into the sink.
+// Returns the sink output and the first fatal error: a build error, a panic raised while wiring a stage
+// or in the sink, the first error Result emitted by a stage, which also cuts off the source, or the context error.
+// A sink returning before the end of the stream, like First, has the rest of the stream drained by the Pipeline.
+// Panics raised in the goroutines of the stages cannot be recovered by the Pipeline, use MapSafe or TryMap
+// to turn them into error Results.
+func (f *Flow[R]) Run(ctx context.Context) (result R, err error) {
+	if f.err != nil {
+		return result, f.err
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	watch := &failureWatch{cutOff: cancel, done: make(chan struct{})}
+	defer close(watch.done)
+	defer cancel()
+	out, err := f.pipeline.wire(runCtx, watch)
+	if err != nil {
+		go drainValue(out)
+		return result, err
+	}
+
+	defer func() {
+		go drainValue(out)
+		if r := recover(); r != nil {
+			err = &PipelineError{Stage: f.name, Err: panicToError(r)}
+		}
+	}()
+	result = f.sink(out)
+	// seals the error slot, an error Result still in flight past the sink is not reported
+	if watch.set(nil) {
+		return result, ctx.Err()
+	}
+	return result, watch.err
+}
+
+// drainValue reads the channel `in` until it is closed
+func drainValue(in reflect.Value) {
+	for _, ok := in.Recv(); ok; _, ok = in.Recv() {
+	}
+}
+
+func isRecvChan(t reflect.Type) bool {
+	return t.Kind() == reflect.Chan && t.ChanDir()&reflect.RecvDir != 0
+}
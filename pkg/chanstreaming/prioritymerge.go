@@ -0,0 +1,162 @@
+package chanstreaming
+
+import (
+	"context"
+	"reflect"
+)
+
+// mergeSources tracks the open sources of the single-goroutine merges
+type mergeSources[T any] struct {
+	sources []<-chan T
+	open    []bool
+	// cases waits for any open source or the cancellation of the context.Context, the last case
+	cases     []reflect.SelectCase
+	openCount int
+}
+
+func newMergeSources[T any](ctx context.Context, sources []<-chan T) *mergeSources[T] {
+	s := &mergeSources[T]{
+		sources:   sources,
+		open:      make([]bool, len(sources)),
+		cases:     make([]reflect.SelectCase, len(sources)+1),
+		openCount: len(sources),
+	}
+	for i, source := range sources {
+		s.open[i] = true
+		s.cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(source)}
+	}
+	s.cases[len(sources)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	return s
+}
+
+func (s *mergeSources[T]) close(i int) {
+	s.open[i] = false
+	s.cases[i].Chan = reflect.Value{}
+	s.openCount--
+}
+
+// poll receives from the source `i` without blocking, reporting whether an element was received.
+func (s *mergeSources[T]) poll(i int) (T, bool) {
+	var zero T
+	if !s.open[i] {
+		return zero, false
+	}
+	select {
+	case x, ok := <-s.sources[i]:
+		if !ok {
+			s.close(i)
+			return zero, false
+		}
+		return x, true
+	default:
+		return zero, false
+	}
+}
+
+// wait blocks until any open source emits, returning its index, or -1 when all the sources are closed
+// or the context.Context is cancelled.
+func (s *mergeSources[T]) wait(ctx context.Context) (int, T) {
+	var zero T
+	for s.openCount > 0 && ctx.Err() == nil {
+		chosen, value, ok := reflect.Select(s.cases)
+		if chosen == len(s.sources) {
+			break
+		}
+		if !ok {
+			s.close(chosen)
+			continue
+		}
+		return chosen, value.Interface().(T)
+	}
+	return -1, zero
+}
+
+// MergePrioritized combines the sources into a single output channel with strict priority by source index:
+// whenever several sources have elements ready, the one with the lowest index is read first.
+// A single goroutine reads all the sources, so a source with an element ready is never overtaken by a lower priority one.
+func MergePrioritized[T any](sources []<-chan T) <-chan T {
+	return MergePrioritizedCtx(context.Background(), sources)
+}
+
+// MergePrioritizedCtx works like MergePrioritized, closing the resulting channel on cancellation of the context.Context.
+func MergePrioritizedCtx[T any](ctx context.Context, sources []<-chan T) <-chan T {
+	out := make(chan T, 1)
+	go func() {
+		defer close(out)
+		s := newMergeSources(ctx, sources)
+		for s.openCount > 0 {
+			polled := false
+			var x T
+			for i := range sources {
+				if x, polled = s.poll(i); polled {
+					break
+				}
+			}
+			if !polled {
+				var i int
+				if i, x = s.wait(ctx); i < 0 {
+					return
+				}
+			}
+			if !sendCtx(ctx, out, x) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// MergeWeighted combines the sources into a single output channel with weighted round-robin:
+// each source in turn gets to emit up to its weight in elements while it has elements ready.
+// Sources with no elements ready give up their turn, and weights below 1 count as 1.
+func MergeWeighted[T any](sources []<-chan T, weights []int) <-chan T {
+	return MergeWeightedCtx(context.Background(), sources, weights)
+}
+
+// MergeWeightedCtx works like MergeWeighted, closing the resulting channel on cancellation of the context.Context.
+func MergeWeightedCtx[T any](ctx context.Context, sources []<-chan T, weights []int) <-chan T {
+	out := make(chan T, 1)
+	go func() {
+		defer close(out)
+		s := newMergeSources(ctx, sources)
+		weight := func(i int) int {
+			if i < len(weights) && weights[i] > 1 {
+				return weights[i]
+			}
+			return 1
+		}
+		current := 0
+		credit := weight(0)
+		next := func() {
+			current = (current + 1) % len(sources)
+			credit = weight(current)
+		}
+
+		for s.openCount > 0 {
+			polled := false
+			var x T
+			for skipped := 0; skipped < len(sources); skipped++ {
+				if x, polled = s.poll(current); polled {
+					break
+				}
+				next()
+			}
+			if !polled {
+				// no source has an element ready, the first one to emit takes the turn
+				var i int
+				if i, x = s.wait(ctx); i < 0 {
+					return
+				}
+				current, credit = i, weight(i)
+			}
+			credit--
+			if credit <= 0 {
+				next()
+			}
+			if !sendCtx(ctx, out, x) {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,214 @@
+package chanstreaming
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter hands out permits for weighted costs.
+// Implementations are safe for concurrent use, so a single RateLimiter can enforce a global rate across several pipelines.
+type RateLimiter interface {
+	// Reserve takes `cost` permits and returns the delay after which they may be used.
+	Reserve(cost float64) time.Duration
+}
+
+// validateRate rejects the rates that would make the limiters wait forever or divide by zero
+func validateRate(limit float64, interval time.Duration) {
+	if !(limit > 0) || interval <= 0 {
+		panic("rate limit and interval must be positive")
+	}
+}
+
+// TokenBucket is a RateLimiter that refills `limit` tokens per `interval` up to `burst` tokens,
+// allowing bursts of up to `burst` cost while keeping the average rate.
+type TokenBucket struct {
+	mu       sync.Mutex
+	clock    Clock
+	rate     float64 // tokens per nanosecond
+	burst    float64
+	tokens   float64
+	lastTime time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket allowing `limit` permits per `interval` with bursts of up to `burst` permits.
+// Panics if `limit` or `interval` is not positive.
+func NewTokenBucket(limit float64, interval time.Duration, burst float64) *TokenBucket {
+	validateRate(limit, interval)
+	return &TokenBucket{
+		clock:    SystemClock,
+		rate:     limit / float64(interval),
+		burst:    burst,
+		tokens:   burst,
+		lastTime: SystemClock.Now(),
+	}
+}
+
+// WithClock switches the TokenBucket to the Clock, refilling it to the full `burst`.
+func (b *TokenBucket) WithClock(clock Clock) *TokenBucket {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clock = clock
+	b.tokens = b.burst
+	b.lastTime = clock.Now()
+	return b
+}
+
+func (b *TokenBucket) Reserve(cost float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	b.tokens = min(b.burst, b.tokens+float64(now.Sub(b.lastTime))*b.rate)
+	b.lastTime = now
+	b.tokens -= cost
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate)
+}
+
+// LeakyBucket is a RateLimiter that lets permits out at the steady pace of `limit` per `interval`, without bursts.
+type LeakyBucket struct {
+	mu    sync.Mutex
+	clock Clock
+	per   time.Duration // time per permit
+	next  time.Time
+}
+
+// NewLeakyBucket creates a LeakyBucket allowing `limit` permits per `interval`.
+// Panics if `limit` or `interval` is not positive.
+func NewLeakyBucket(limit float64, interval time.Duration) *LeakyBucket {
+	validateRate(limit, interval)
+	return &LeakyBucket{
+		clock: SystemClock,
+		per:   time.Duration(float64(interval) / limit),
+	}
+}
+
+// WithClock switches the LeakyBucket to the Clock.
+func (b *LeakyBucket) WithClock(clock Clock) *LeakyBucket {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clock = clock
+	b.next = time.Time{}
+	return b
+}
+
+func (b *LeakyBucket) Reserve(cost float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	start := b.next
+	if start.Before(now) {
+		start = now
+	}
+	b.next = start.Add(time.Duration(cost * float64(b.per)))
+	return start.Sub(now)
+}
+
+// slidingLogEntry is a permit taken from SlidingWindowLog
+type slidingLogEntry struct {
+	ts   time.Time
+	cost float64
+}
+
+// SlidingWindowLog is a RateLimiter that keeps a log of the permits taken, allowing up to `limit` permits
+// within any `window` long period.
+type SlidingWindowLog struct {
+	mu     sync.Mutex
+	clock  Clock
+	limit  float64
+	window time.Duration
+	log    []slidingLogEntry
+	total  float64
+}
+
+// NewSlidingWindowLog creates a SlidingWindowLog allowing `limit` permits per any `window` long period.
+// Panics if `limit` or `window` is not positive.
+func NewSlidingWindowLog(limit float64, window time.Duration) *SlidingWindowLog {
+	validateRate(limit, window)
+	return &SlidingWindowLog{
+		clock:  SystemClock,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// WithClock switches the SlidingWindowLog to the Clock, forgetting the permits taken.
+func (l *SlidingWindowLog) WithClock(clock Clock) *SlidingWindowLog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = clock
+	l.log = nil
+	l.total = 0
+	return l
+}
+
+func (l *SlidingWindowLog) Reserve(cost float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+
+	// drop the permits that left the window
+	dropCount := 0
+	for dropCount < len(l.log) && now.Sub(l.log[dropCount].ts) >= l.window {
+		l.total -= l.log[dropCount].cost
+		dropCount++
+	}
+	l.log = l.log[dropCount:]
+
+	// find the earliest moment enough permits leave the window, a cost above the limit waits for the whole log
+	at := now
+	remaining := l.total
+	for i := 0; i < len(l.log) && remaining+cost > l.limit; i++ {
+		remaining -= l.log[i].cost
+		at = l.log[i].ts.Add(l.window)
+	}
+	if len(l.log) > 0 && at.Before(l.log[len(l.log)-1].ts) {
+		// keep the log ordered
+		at = l.log[len(l.log)-1].ts
+	}
+
+	l.log = append(l.log, slidingLogEntry{ts: at, cost: cost})
+	l.total += cost
+	return at.Sub(now)
+}
+
+// RateLimit delays the source items so that each item takes a single permit from the RateLimiter.
+func RateLimit[T any](limiter RateLimiter) func(in <-chan T) <-chan T {
+	return RateLimitCtx[T](context.Background(), limiter)
+}
+
+// RateLimitCtx works like RateLimit, closing the resulting channel on cancellation of the context.Context.
+func RateLimitCtx[T any](ctx context.Context, limiter RateLimiter) func(in <-chan T) <-chan T {
+	return RateLimitWeightedCtx[T, int](ctx, limiter, func(T) int { return 1 })
+}
+
+// RateLimitWeighted delays the source items so that each item takes the number of permits computed with `sizeFn`.
+func RateLimitWeighted[T any, N NumberType](limiter RateLimiter, sizeFn func(element T) N) func(in <-chan T) <-chan T {
+	return RateLimitWeightedCtx[T, N](context.Background(), limiter, sizeFn)
+}
+
+// RateLimitWeightedCtx works like RateLimitWeighted, closing the resulting channel on cancellation of the context.Context.
+// The delays are waited out on the context.Context Clock, which is expected to match the Clock of the RateLimiter.
+func RateLimitWeightedCtx[T any, N NumberType](ctx context.Context, limiter RateLimiter, sizeFn func(element T) N) func(in <-chan T) <-chan T {
+	return func(in <-chan T) <-chan T {
+		out := make(chan T, 1)
+		go func() {
+			defer close(out)
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if !sleepCtx(ctx, limiter.Reserve(float64(sizeFn(x)))) {
+					return
+				}
+				if !sendCtx(ctx, out, x) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
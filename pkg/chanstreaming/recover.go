@@ -1,28 +1,28 @@
 package chanstreaming
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 )
 
+// panicToError converts a recovered panic value to an error.
+func panicToError(r any) error {
+	// check if the panic is an error
+	if err, ok := r.(error); ok {
+		return err
+	}
+	// convert panic to error message str
+	return errors.New(fmt.Sprint(r))
+}
+
 func NewAsyncResult[T any](fn func() T) <-chan Result[T] {
 	output := make(chan Result[T], 1)
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				// check if the panic is an error
-				if _, ok := r.(error); ok {
-					result := Result[T]{
-						Error: r.(error),
-					}
-					output <- result
-				} else {
-					// convert panic to error message str
-					result := Result[T]{
-						Error: errors.New(fmt.Sprint(r)),
-					}
-					output <- result
-				}
+				output <- Result[T]{Error: panicToError(r)}
 			}
 			close(output)
 		}()
@@ -33,16 +33,91 @@ func NewAsyncResult[T any](fn func() T) <-chan Result[T] {
 	return output
 }
 
+// NewAsyncResultCtx works like NewAsyncResult, passing the context.Context to `fn`.
+// Once the context.Context is done the resulting channel receives its cause right away instead of waiting for `fn`,
+// whose result is then discarded.
+func NewAsyncResultCtx[T any](ctx context.Context, fn func(ctx context.Context) T) <-chan Result[T] {
+	return newAsyncResultCtx(ctx, fn, nil, nil)
+}
+
+// newAsyncResultCtx works like NewAsyncResultCtx, handing the result of `fn` to `onStraggler`, if any, when it completes
+// after being abandoned, and calling `release`, if any, once `fn` completes or is abandoned.
+func newAsyncResultCtx[T any](ctx context.Context, fn func(ctx context.Context) T, onStraggler func(late Result[T]), release func()) <-chan Result[T] {
+	output := make(chan Result[T], 1)
+	completed := make(chan Result[T], 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				completed <- NewError[T](panicToError(r))
+			}
+		}()
+		completed <- NewResult(fn(ctx))
+	}()
+	go func() {
+		var result Result[T]
+		abandoned := false
+		select {
+		case result = <-completed:
+		case <-ctx.Done():
+			// `fn` may have completed along with the context.Context, its result is then not late
+			select {
+			case result = <-completed:
+			default:
+				result, abandoned = NewError[T](context.Cause(ctx)), true
+			}
+		}
+		if release != nil {
+			release()
+		}
+		output <- result
+		// the output is done before waiting for the straggler
+		close(output)
+		if abandoned && onStraggler != nil {
+			onStraggler(<-completed)
+		}
+	}()
+	return output
+}
+
+// contextWithClockTimeout works like context.WithTimeout, measuring the timeout with the Clock of the context.Context.
+// The cause of the context.Context is context.DeadlineExceeded once the timeout elapses, zero or less means no timeout.
+func contextWithClockTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	timeoutCtx, cancel := context.WithCancelCause(ctx)
+	timer := ClockFromContext(ctx).NewTimer(timeout)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			cancel(context.DeadlineExceeded)
+		case <-timeoutCtx.Done():
+		}
+	}()
+	return timeoutCtx, func() { cancel(context.Canceled) }
+}
+
 func MapSafeAsync[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan <-chan Result[R] {
-	return Map[T, <-chan Result[R]](func(in T) <-chan Result[R] {
+	return MapSafeAsyncCtx[T, R](context.Background(), fn, maxWorkers)
+}
+
+// MapSafeAsyncCtx works like MapSafeAsync, closing the resulting channel on cancellation of the context.Context.
+func MapSafeAsyncCtx[T any, R any](ctx context.Context, fn func(T) R, maxWorkers int) func(in <-chan T) <-chan <-chan Result[R] {
+	return MapCtx[T, <-chan Result[R]](ctx, func(in T) <-chan Result[R] {
 		return NewAsyncResult[R](func() R { return fn(in) })
 	}, maxWorkers)
 }
 
 func MapSafe[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan Result[R] {
+	return MapSafeCtx[T, R](context.Background(), fn, maxWorkers)
+}
+
+// MapSafeCtx works like MapSafe, closing the resulting channel on cancellation of the context.Context.
+func MapSafeCtx[T any, R any](ctx context.Context, fn func(T) R, maxWorkers int) func(in <-chan T) <-chan Result[R] {
 	return func(in <-chan T) <-chan Result[R] {
-		x := MapSafeAsync[T, R](fn, maxWorkers)(in)
-		flattened := FlatMap[<-chan Result[R], Result[R]](func(asyncResult <-chan Result[R]) <-chan Result[R] {
+		x := MapSafeAsyncCtx[T, R](ctx, fn, maxWorkers)(in)
+		flattened := FlatMapCtx[<-chan Result[R], Result[R]](ctx, func(asyncResult <-chan Result[R]) <-chan Result[R] {
 			return asyncResult
 		})(x)
 		return flattened
@@ -50,15 +125,52 @@ func MapSafe[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-cha
 }
 
 func MapUnorderedSafeAsync[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan <-chan Result[R] {
-	return MapUnordered[T, <-chan Result[R]](func(in T) <-chan Result[R] {
+	return MapUnorderedSafeAsyncCtx[T, R](context.Background(), fn, maxWorkers)
+}
+
+// MapUnorderedSafeAsyncCtx works like MapUnorderedSafeAsync, closing the resulting channel on cancellation of the context.Context.
+func MapUnorderedSafeAsyncCtx[T any, R any](ctx context.Context, fn func(T) R, maxWorkers int) func(in <-chan T) <-chan <-chan Result[R] {
+	return MapUnorderedCtx[T, <-chan Result[R]](ctx, func(in T) <-chan Result[R] {
 		return NewAsyncResult[R](func() R { return fn(in) })
 	}, maxWorkers)
 }
 
 func MapUnorderedSafe[T any, R any](fn func(T) R, maxWorkers int) func(in <-chan T) <-chan Result[R] {
+	return MapUnorderedSafeCtx[T, R](context.Background(), fn, maxWorkers)
+}
+
+// MapUnorderedSafeCtx works like MapUnorderedSafe, closing the resulting channel on cancellation of the context.Context.
+func MapUnorderedSafeCtx[T any, R any](ctx context.Context, fn func(T) R, maxWorkers int) func(in <-chan T) <-chan Result[R] {
 	return func(in <-chan T) <-chan Result[R] {
-		x := MapUnorderedSafeAsync[T, R](fn, maxWorkers)(in)
-		flattened := FlatMap[<-chan Result[R], Result[R]](func(asyncResult <-chan Result[R]) <-chan Result[R] {
+		x := MapUnorderedSafeAsyncCtx[T, R](ctx, fn, maxWorkers)(in)
+		flattened := FlatMapCtx[<-chan Result[R], Result[R]](ctx, func(asyncResult <-chan Result[R]) <-chan Result[R] {
+			return asyncResult
+		})(x)
+		return flattened
+	}
+}
+
+// MapSafeTimeout works like MapSafe, passing `fn` a context.Context that expires `timeout` after the call starts.
+// A call still running on expiry is abandoned and a context.DeadlineExceeded Result is emitted in its place,
+// so a hung call cannot hold a worker slot forever. The abandoned calls keep running in the background,
+// `onStraggler`, if not nil, receives the element and the late Result of each one that eventually completes.
+func MapSafeTimeout[T any, R any](fn func(ctx context.Context, x T) R, timeout time.Duration, maxWorkers int, onStraggler func(x T, late Result[R])) func(in <-chan T) <-chan Result[R] {
+	return MapSafeTimeoutCtx[T, R](context.Background(), fn, timeout, maxWorkers, onStraggler)
+}
+
+// MapSafeTimeoutCtx works like MapSafeTimeout, closing the resulting channel on cancellation of the context.Context.
+// The timeouts are measured with the Clock of the context.Context.
+func MapSafeTimeoutCtx[T any, R any](ctx context.Context, fn func(ctx context.Context, x T) R, timeout time.Duration, maxWorkers int, onStraggler func(x T, late Result[R])) func(in <-chan T) <-chan Result[R] {
+	return func(in <-chan T) <-chan Result[R] {
+		x := MapCtx[T, <-chan Result[R]](ctx, func(in T) <-chan Result[R] {
+			itemCtx, cancel := contextWithClockTimeout(ctx, timeout)
+			var report func(late Result[R])
+			if onStraggler != nil {
+				report = func(late Result[R]) { onStraggler(in, late) }
+			}
+			return newAsyncResultCtx(itemCtx, func(itemCtx context.Context) R { return fn(itemCtx, in) }, report, cancel)
+		}, maxWorkers)(in)
+		flattened := FlatMapCtx[<-chan Result[R], Result[R]](ctx, func(asyncResult <-chan Result[R]) <-chan Result[R] {
 			return asyncResult
 		})(x)
 		return flattened
@@ -0,0 +1,149 @@
+package chanstreaming
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrHeadOfLineTimeout is the error of the Result emitted by MapOrderedBounded in place of a call that missed its deadline.
+var ErrHeadOfLineTimeout = errors.New("head of line timeout")
+
+// reorderEntry is an element of MapOrderedBounded, from the start of its call to its emission
+type reorderEntry[R any] struct {
+	result   chan Result[R]
+	deadline time.Time
+	done     bool
+	value    Result[R]
+}
+
+// MapOrderedBounded applies a fallible transformation function to each element in parallel, preserving order,
+// with up to `maxWorkers` calls running at once. Unlike Map, the calls keep starting while the head of the line
+// is slow, the completed results waiting behind it in a reorder buffer; new calls are held back while
+// more than `maxBuffered` completed results are waiting to be emitted.
+// With a positive `timeout`, a call still running `timeout` after its start is given up once it is at the head of the line:
+// an ErrHeadOfLineTimeout Result is emitted in its place and its worker slot is freed, while the call keeps running
+// in the background and its result is discarded. Errors and panics of `fn` are emitted as error Results.
+func MapOrderedBounded[T any, R any](fn func(T) (R, error), maxWorkers int, maxBuffered int, timeout time.Duration) func(in <-chan T) <-chan Result[R] {
+	return MapOrderedBoundedCtx[T, R](context.Background(), fn, maxWorkers, maxBuffered, timeout)
+}
+
+// MapOrderedBoundedCtx works like MapOrderedBounded, closing the resulting channel on cancellation of the context.Context.
+// Calls of `fn` that are already running are not interrupted, their results are discarded.
+func MapOrderedBoundedCtx[T any, R any](ctx context.Context, fn func(T) (R, error), maxWorkers int, maxBuffered int, timeout time.Duration) func(in <-chan T) <-chan Result[R] {
+	maxWorkers = max(maxWorkers, 1)
+	maxBuffered = max(maxBuffered, 0)
+	return func(in <-chan T) <-chan Result[R] {
+		out := make(chan Result[R], 1)
+		go func() {
+			defer close(out)
+			clock := ClockFromContext(ctx)
+			// wake is signalled by the calls as they complete, the line is then checked for the completed entries
+			wake := make(chan struct{}, 1)
+			var line []*reorderEntry[R]
+			running, buffered := 0, 0
+
+			var timer Timer
+			var timerC <-chan time.Time
+			var timerFor *reorderEntry[R]
+			stopTimer := func() {
+				if timer != nil {
+					timer.Stop()
+				}
+				timer, timerC, timerFor = nil, nil, nil
+			}
+			defer stopTimer()
+
+			source := in
+			for source != nil || len(line) > 0 {
+				// the head timer follows the entry at the head of the line while its call is running
+				if timeout > 0 && len(line) > 0 && !line[0].done && timerFor != line[0] {
+					stopTimer()
+					timerFor = line[0]
+					timer = clock.NewTimer(timerFor.deadline.Sub(clock.Now()))
+					timerC = timer.C()
+				}
+
+				receive := source
+				if running >= maxWorkers || buffered > maxBuffered {
+					receive = nil
+				}
+				var send chan Result[R]
+				var head Result[R]
+				if len(line) > 0 && line[0].done {
+					send = out
+					head = line[0].value
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case send <- head:
+					if timerFor == line[0] {
+						stopTimer()
+					}
+					line[0] = nil
+					line = line[1:]
+					buffered--
+				case <-wake:
+					for _, entry := range line {
+						if entry.done {
+							continue
+						}
+						select {
+						case entry.value = <-entry.result:
+							entry.done = true
+							running--
+							buffered++
+							if timerFor == entry {
+								stopTimer()
+							}
+						default:
+						}
+					}
+				case <-timerC:
+					timer, timerC = nil, nil
+					if len(line) == 0 {
+						timerFor = nil
+						continue
+					}
+					if head := line[0]; head == timerFor && !head.done && !clock.Now().Before(head.deadline) {
+						// the call may have completed without the wake being handled yet
+						select {
+						case head.value = <-head.result:
+						default:
+							head.value = NewError[R](ErrHeadOfLineTimeout)
+						}
+						head.done = true
+						running--
+						buffered++
+					} else {
+						// the timer was set for an entry that is no longer running at the head, set it again
+						timerFor = nil
+					}
+				case x, ok := <-receive:
+					if !ok {
+						source = nil
+						continue
+					}
+					entry := &reorderEntry[R]{result: make(chan Result[R], 1), deadline: clock.Now().Add(timeout)}
+					line = append(line, entry)
+					running++
+					go func() {
+						data, err := tryCall(func() (R, error) { return fn(x) })
+						if err != nil {
+							entry.result <- NewError[R](err)
+						} else {
+							entry.result <- NewResult(data)
+						}
+						select {
+						case wake <- struct{}{}:
+						default:
+						}
+					}()
+				}
+			}
+		}()
+		return out
+	}
+}
@@ -1,5 +1,7 @@
 package chanstreaming
 
+import "context"
+
 type Result[T any] struct {
 	Data  T
 	Error error
@@ -8,16 +10,27 @@ type Result[T any] struct {
 // Catch transforms Result[T] back to just T channel, applying fn function on error to
 // give control of error handling
 func Catch[T any](fn func(error)) func(in <-chan Result[T]) <-chan T {
+	return CatchCtx[T](context.Background(), fn)
+}
+
+// CatchCtx works like Catch, closing the resulting channel on cancellation of the context.Context.
+func CatchCtx[T any](ctx context.Context, fn func(error)) func(in <-chan Result[T]) <-chan T {
 	return func(in <-chan Result[T]) <-chan T {
 		out := make(chan T, 1)
 		go func() {
 			defer close(out)
-			for x := range in {
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
 				if x.Error != nil {
 					fn(x.Error)
 					continue
 				}
-				out <- x.Data
+				if !sendCtx(ctx, out, x.Data) {
+					return
+				}
 			}
 		}()
 		return out
@@ -34,6 +47,11 @@ func Panic[T any](in <-chan Result[T]) <-chan T {
 	return Catch[T](func(err error) { panic(err) })(in)
 }
 
+// failure exposes the error of the Result to the type-erased Pipeline.
+func (r Result[T]) failure() error {
+	return r.Error
+}
+
 func NewResult[T any](data T) Result[T] {
 	return Result[T]{Data: data}
 }
@@ -59,10 +77,11 @@ func IsResult[T any](in Result[T]) bool {
 	return !IsError(in)
 }
 
+// MapError transforms the error of the Result with `fn`, leaving successful results untouched.
 func MapError[T any](fn func(error) error) func(in Result[T]) Result[T] {
 	return func(in Result[T]) Result[T] {
 		if in.Error != nil {
-			return NewError[T](in.Error)
+			return NewError[T](fn(in.Error))
 		}
 		return in
 	}
@@ -0,0 +1,242 @@
+package chanstreaming
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// BackoffStrategy defines how the delay between the attempts of a RetryPolicy grows, the delay is capped by MaxDelay.
+type BackoffStrategy int
+
+const (
+	// ConstantBackoff waits BaseDelay between the attempts.
+	ConstantBackoff BackoffStrategy = iota
+	// ExponentialBackoff doubles the delay after each failed attempt, starting with BaseDelay.
+	ExponentialBackoff
+	// DecorrelatedJitterBackoff picks a random delay between BaseDelay and 3x the previous delay.
+	DecorrelatedJitterBackoff
+)
+
+// maxDuration is the delay the growing backoffs saturate at
+const maxDuration = time.Duration(math.MaxInt64)
+
+// RetryPolicy defines how a failing call is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one, values below 1 mean a single attempt
+	MaxAttempts int
+	Backoff     BackoffStrategy
+	BaseDelay   time.Duration
+	// MaxDelay caps the delay between the attempts, zero means no cap
+	MaxDelay time.Duration
+	// Retryable classifies the errors, nil retries every error
+	Retryable func(err error) bool
+	// Budget limits the retries shared by all the calls using the policy, nil means no limit
+	Budget *RetryBudget
+}
+
+// RetryResult is the final Result of a retried call along with the attempts metadata.
+type RetryResult[T any] struct {
+	Result[T]
+	// Attempts is the number of attempts made
+	Attempts int
+	// Errors holds the errors of all the failed attempts in order
+	Errors []error
+	// Elapsed is the time spent on all the attempts including the delays
+	Elapsed time.Duration
+}
+
+// RetryBudget limits the share of retries among all calls, preventing retry storms against a failing dependency.
+// Each call deposits `ratio` tokens, each retry withdraws one token, the balance is capped by `maxTokens`.
+type RetryBudget struct {
+	mu        sync.Mutex
+	ratio     float64
+	tokens    float64
+	maxTokens float64
+}
+
+// NewRetryBudget creates a full RetryBudget that allows `ratio` retries per call on average
+// and up to `maxTokens` retries in a burst.
+func NewRetryBudget(ratio float64, maxTokens int) *RetryBudget {
+	return &RetryBudget{
+		ratio:     ratio,
+		tokens:    float64(maxTokens),
+		maxTokens: float64(maxTokens),
+	}
+}
+
+func (b *RetryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.maxTokens)
+}
+
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (p *RetryPolicy) WithMaxAttempts(maxAttempts int) RetryPolicy {
+	policy := *p
+	policy.MaxAttempts = maxAttempts
+	return policy
+}
+
+func (p *RetryPolicy) WithBackoff(backoff BackoffStrategy, baseDelay time.Duration, maxDelay time.Duration) RetryPolicy {
+	policy := *p
+	policy.Backoff = backoff
+	policy.BaseDelay = baseDelay
+	policy.MaxDelay = maxDelay
+	return policy
+}
+
+func (p *RetryPolicy) WithRetryable(retryable func(err error) bool) RetryPolicy {
+	policy := *p
+	policy.Retryable = retryable
+	return policy
+}
+
+func (p *RetryPolicy) WithBudget(budget *RetryBudget) RetryPolicy {
+	policy := *p
+	policy.Budget = budget
+	return policy
+}
+
+// delay computes the pause before the next attempt given the number of failed attempts and the previous delay.
+func (p *RetryPolicy) delay(failedAttempts int, previous time.Duration) time.Duration {
+	base := max(p.BaseDelay, 0)
+	var d time.Duration
+	switch p.Backoff {
+	case ExponentialBackoff:
+		shift := min(max(failedAttempts-1, 0), 63)
+		d = base << shift
+		if d>>shift != base {
+			// the doubling overflowed
+			d = maxDuration
+		}
+	case DecorrelatedJitterBackoff:
+		upper := maxDuration
+		if previous <= maxDuration/3 {
+			upper = max(previous*3, base)
+		}
+		// base+span can't overflow, the random delay is drawn from [0, span]
+		span := int64(upper - base)
+		if span < math.MaxInt64 {
+			span++
+		}
+		d = base + time.Duration(rand.Int63n(span))
+	default:
+		d = base
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// shouldRetry reports whether a call that failed with `err` after `attempts` attempts is to be retried.
+func (p *RetryPolicy) shouldRetry(err error, attempts int) bool {
+	if attempts >= p.MaxAttempts {
+		return false
+	}
+	if p.Retryable != nil && !p.Retryable(err) {
+		return false
+	}
+	return p.Budget == nil || p.Budget.withdraw()
+}
+
+// tryCall invokes `fn`, converting a panic to an error.
+func tryCall[T any](fn func() (T, error)) (data T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicToError(r)
+		}
+	}()
+	return fn()
+}
+
+// Retry invokes `fn` until it succeeds or the RetryPolicy gives up, treating panics as errors.
+func Retry[T any](fn func() (T, error), policy RetryPolicy) RetryResult[T] {
+	return RetryCtx[T](context.Background(), fn, policy)
+}
+
+// RetryCtx works like Retry, giving up on cancellation of the context.Context with the context error.
+func RetryCtx[T any](ctx context.Context, fn func() (T, error), policy RetryPolicy) RetryResult[T] {
+	clock := ClockFromContext(ctx)
+	start := clock.Now()
+	if policy.Budget != nil {
+		policy.Budget.deposit()
+	}
+
+	result := RetryResult[T]{}
+	delay := time.Duration(0)
+	for {
+		data, err := tryCall(fn)
+		result.Attempts++
+		if err == nil {
+			result.Result = NewResult(data)
+			break
+		}
+		result.Errors = append(result.Errors, err)
+		result.Result = NewError[T](err)
+		if !policy.shouldRetry(err, result.Attempts) {
+			break
+		}
+		delay = policy.delay(result.Attempts, delay)
+		if !sleepCtx(ctx, delay) {
+			result.Result = NewError[T](ctx.Err())
+			break
+		}
+	}
+	result.Elapsed = clock.Now().Sub(start)
+	return result
+}
+
+// NewAsyncRetry works like NewAsyncResult, retrying `fn` as dictated by the RetryPolicy.
+func NewAsyncRetry[T any](fn func() (T, error), policy RetryPolicy) <-chan RetryResult[T] {
+	return NewAsyncRetryCtx[T](context.Background(), fn, policy)
+}
+
+// NewAsyncRetryCtx works like NewAsyncRetry, giving up on cancellation of the context.Context.
+func NewAsyncRetryCtx[T any](ctx context.Context, fn func() (T, error), policy RetryPolicy) <-chan RetryResult[T] {
+	output := make(chan RetryResult[T], 1)
+	go func() {
+		defer close(output)
+		output <- RetryCtx(ctx, fn, policy)
+	}()
+	return output
+}
+
+// MapRetry applies a fallible transformation function to each element in parallel, preserving order,
+// retrying each element as dictated by the RetryPolicy.
+func MapRetry[T any, R any](fn func(T) (R, error), policy RetryPolicy, maxWorkers int) func(in <-chan T) <-chan RetryResult[R] {
+	return MapRetryCtx[T, R](context.Background(), fn, policy, maxWorkers)
+}
+
+// MapRetryCtx works like MapRetry, closing the resulting channel and interrupting the backoff delays
+// on cancellation of the context.Context.
+func MapRetryCtx[T any, R any](ctx context.Context, fn func(T) (R, error), policy RetryPolicy, maxWorkers int) func(in <-chan T) <-chan RetryResult[R] {
+	return MapCtx[T, RetryResult[R]](ctx, func(x T) RetryResult[R] {
+		return RetryCtx(ctx, func() (R, error) { return fn(x) }, policy)
+	}, maxWorkers)
+}
+
+// MapUnorderedRetry works like MapRetry without preserving order.
+func MapUnorderedRetry[T any, R any](fn func(T) (R, error), policy RetryPolicy, maxWorkers int) func(in <-chan T) <-chan RetryResult[R] {
+	return MapUnorderedRetryCtx[T, R](context.Background(), fn, policy, maxWorkers)
+}
+
+// MapUnorderedRetryCtx works like MapUnorderedRetry, closing the resulting channel and interrupting
+// the backoff delays on cancellation of the context.Context.
+func MapUnorderedRetryCtx[T any, R any](ctx context.Context, fn func(T) (R, error), policy RetryPolicy, maxWorkers int) func(in <-chan T) <-chan RetryResult[R] {
+	return MapUnorderedCtx[T, RetryResult[R]](ctx, func(x T) RetryResult[R] {
+		return RetryCtx(ctx, func() (R, error) { return fn(x) }, policy)
+	}, maxWorkers)
+}
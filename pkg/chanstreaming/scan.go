@@ -1,6 +1,7 @@
 package chanstreaming
 
 import (
+	"context"
 	"errors"
 	"time"
 )
@@ -8,14 +9,25 @@ import (
 // Scan produces a channel that takes initialState then evolves the state with each new element from consuming from the original source.
 // The resulting channel will emit a new state on each new source element.
 func Scan[TIn any, TState any](fn func(TState, TIn) TState, initialState TState) func(in <-chan TIn) <-chan TState {
+	return ScanCtx[TIn, TState](context.Background(), fn, initialState)
+}
+
+// ScanCtx works like Scan, closing the resulting channel on cancellation of the context.Context.
+func ScanCtx[TIn any, TState any](ctx context.Context, fn func(TState, TIn) TState, initialState TState) func(in <-chan TIn) <-chan TState {
 	return func(in <-chan TIn) <-chan TState {
 		out := make(chan TState, 1)
 		go func() {
 			defer close(out)
 			state := initialState
-			for x := range in {
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
 				state = fn(state, x)
-				out <- state
+				if !sendCtx(ctx, out, state) {
+					return
+				}
 			}
 		}()
 		return out
@@ -24,14 +36,27 @@ func Scan[TIn any, TState any](fn func(TState, TIn) TState, initialState TState)
 
 // Fold works similarly to Scan, difference that only the last value of the accumulated state is sent to the resulting channel.
 func Fold[TIn any, TState any](fn func(TState, TIn) TState, initialState TState) func(in <-chan TIn) <-chan TState {
+	return FoldCtx[TIn, TState](context.Background(), fn, initialState)
+}
+
+// FoldCtx works like Fold. On cancellation of the context.Context the resulting channel is closed
+// without emitting the accumulated state.
+func FoldCtx[TIn any, TState any](ctx context.Context, fn func(TState, TIn) TState, initialState TState) func(in <-chan TIn) <-chan TState {
 	return func(in <-chan TIn) <-chan TState {
 		out := make(chan TState, 1)
 		go func() {
 			defer close(out)
 			state := initialState
-			for x := range in {
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					break
+				}
 				state = fn(state, x)
 			}
+			if ctx.Err() != nil {
+				return
+			}
 			out <- state
 		}()
 		return out
@@ -68,13 +93,19 @@ func NewWindowOverflowError() WindowOverflowError {
 
 // WithSlidingWindow creates a sliding window of elements with behavior as specified in the windowConfig.
 func WithSlidingWindow[T any](windowConfig WindowConfig) func(in <-chan T) <-chan []T {
+	return WithSlidingWindowCtx[T](context.Background(), windowConfig)
+}
+
+// WithSlidingWindowCtx works like WithSlidingWindow, closing the resulting channel on cancellation of the context.Context.
+func WithSlidingWindowCtx[T any](ctx context.Context, windowConfig WindowConfig) func(in <-chan T) <-chan []T {
 	if windowConfig == unbound {
 		panic("window config must be set")
 	}
 
+	clock := ClockFromContext(ctx)
 	zer := []timedWindowElement[T]{}
-	scanner := Scan[T, []timedWindowElement[T]](func(state []timedWindowElement[T], x T) []timedWindowElement[T] {
-		tsNow := time.Now()
+	scanner := ScanCtx[T, []timedWindowElement[T]](ctx, func(state []timedWindowElement[T], x T) []timedWindowElement[T] {
+		tsNow := clock.Now()
 		if windowConfig.Duration != 0 {
 			dropCount := 0
 			for i := 0; i < len(state); i++ {
@@ -136,7 +167,7 @@ func WithSlidingWindow[T any](windowConfig WindowConfig) func(in <-chan T) <-cha
 		return dataSlice
 	}
 
-	mapper := Mapped[[]timedWindowElement[T], []T](batchMapper)
+	mapper := MappedCtx[[]timedWindowElement[T], []T](ctx, batchMapper)
 
 	return func(in <-chan T) <-chan []T {
 		scanned := scanner(in)
@@ -0,0 +1,196 @@
+package chanstreaming
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// KeyedWindow is a closed window of the elements sharing the Key.
+type KeyedWindow[K comparable, T any] struct {
+	Key K
+	Window[T]
+}
+
+// SessionConfig caps the sessions of SessionWindow.
+type SessionConfig struct {
+	// MaxLength closes a session this long after its start even if it is still active, zero means unbounded
+	MaxLength time.Duration
+	// MaxSessions limits the number of open sessions, zero means unbounded
+	MaxSessions int
+	// OverflowStrategy decides what happens to an element opening a session beyond MaxSessions:
+	// Ignore opens it anyway, Error panics, DropTail drops the element and DropHead closes the oldest open session first
+	OverflowStrategy OverflowStrategy
+}
+
+func (c *SessionConfig) WithMaxLength(maxLength time.Duration) SessionConfig {
+	return SessionConfig{
+		MaxLength:        maxLength,
+		MaxSessions:      c.MaxSessions,
+		OverflowStrategy: c.OverflowStrategy,
+	}
+}
+
+func (c *SessionConfig) WithMaxSessions(maxSessions int) SessionConfig {
+	return SessionConfig{
+		MaxLength:        c.MaxLength,
+		MaxSessions:      maxSessions,
+		OverflowStrategy: c.OverflowStrategy,
+	}
+}
+
+func (c *SessionConfig) WithOverflowStrategy(overflowStrategy OverflowStrategy) SessionConfig {
+	return SessionConfig{
+		MaxLength:        c.MaxLength,
+		MaxSessions:      c.MaxSessions,
+		OverflowStrategy: overflowStrategy,
+	}
+}
+
+// openSession is a session of SessionWindow still accepting elements
+type openSession[T any] struct {
+	start    time.Time
+	last     time.Time
+	elements []T
+}
+
+// SessionWindow groups the source elements by the key extracted with `keyFn` into sessions of activity
+// separated by at least `gap` of silence, by their arrival time.
+// A session is emitted once it closes, its End being `gap` after its last element or the end of its MaxLength;
+// the sessions still open are emitted when the source closes.
+func SessionWindow[K comparable, T any](keyFn func(T) K, gap time.Duration, config SessionConfig) func(in <-chan T) <-chan KeyedWindow[K, T] {
+	return SessionWindowCtx[K, T](context.Background(), keyFn, gap, config)
+}
+
+// SessionWindowCtx works like SessionWindow, closing the resulting channel on cancellation of the context.Context.
+func SessionWindowCtx[K comparable, T any](ctx context.Context, keyFn func(T) K, gap time.Duration, config SessionConfig) func(in <-chan T) <-chan KeyedWindow[K, T] {
+	return func(in <-chan T) <-chan KeyedWindow[K, T] {
+		out := make(chan KeyedWindow[K, T], 1)
+		go func() {
+			defer close(out)
+			clock := ClockFromContext(ctx)
+			sessions := make(map[K]*openSession[T])
+			deadline := func(s *openSession[T]) time.Time {
+				end := s.last.Add(gap)
+				if config.MaxLength > 0 {
+					end = minTime(end, s.start.Add(config.MaxLength))
+				}
+				return end
+			}
+
+			// the timer wakes up at the earliest deadline at the time it was set, sessions extended since then are checked again
+			var timer Timer
+			var timerC <-chan time.Time
+			schedule := func() {
+				if timer != nil {
+					timer.Stop()
+					timer, timerC = nil, nil
+				}
+				if len(sessions) == 0 {
+					return
+				}
+				var next time.Time
+				first := true
+				for _, s := range sessions {
+					if end := deadline(s); first || end.Before(next) {
+						next, first = end, false
+					}
+				}
+				timer = clock.NewTimer(next.Sub(clock.Now()))
+				timerC = timer.C()
+			}
+			defer func() {
+				if timer != nil {
+					timer.Stop()
+				}
+			}()
+
+			emit := func(key K, s *openSession[T]) bool {
+				delete(sessions, key)
+				return sendCtx(ctx, out, KeyedWindow[K, T]{
+					Key:    key,
+					Window: Window[T]{Start: s.start, End: deadline(s), Elements: s.elements},
+				})
+			}
+			// closeSessions emits the sessions for which `closing` holds, in the order of their deadlines
+			closeSessions := func(closing func(s *openSession[T]) bool) bool {
+				keys := []K{}
+				for key, s := range sessions {
+					if closing(s) {
+						keys = append(keys, key)
+					}
+				}
+				sort.SliceStable(keys, func(i, j int) bool {
+					return deadline(sessions[keys[i]]).Before(deadline(sessions[keys[j]]))
+				})
+				for _, key := range keys {
+					if !emit(key, sessions[key]) {
+						return false
+					}
+				}
+				return true
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-timerC:
+					now := clock.Now()
+					if !closeSessions(func(s *openSession[T]) bool { return !now.Before(deadline(s)) }) {
+						return
+					}
+					schedule()
+				case x, ok := <-in:
+					if !ok {
+						closeSessions(func(*openSession[T]) bool { return true })
+						return
+					}
+					now := clock.Now()
+					key := keyFn(x)
+					s, found := sessions[key]
+					if found && !now.Before(deadline(s)) {
+						// the session is due, even though the timer has not been handled yet
+						if !emit(key, s) {
+							return
+						}
+						found = false
+					}
+					if found {
+						s.last = now
+						s.elements = append(s.elements, x)
+						continue
+					}
+
+					if config.MaxSessions > 0 && len(sessions) >= config.MaxSessions {
+						switch config.OverflowStrategy {
+						case Ignore:
+						case DropTail:
+							continue
+						case DropHead:
+							var oldestKey K
+							var oldest *openSession[T]
+							for k, open := range sessions {
+								if oldest == nil || open.start.Before(oldest.start) {
+									oldestKey, oldest = k, open
+								}
+							}
+							if !emit(oldestKey, oldest) {
+								return
+							}
+						case Error:
+							fallthrough
+						default:
+							panic(NewWindowOverflowError())
+						}
+					}
+					sessions[key] = &openSession[T]{start: now, last: now, elements: []T{x}}
+					if timer == nil {
+						schedule()
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
@@ -0,0 +1,249 @@
+package chanstreaming
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// Codec converts the elements spilled by SpillBuffer to bytes and back.
+type Codec[T any] interface {
+	Encode(x T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// JSONCodec is a Codec encoding the elements as JSON.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(x T) ([]byte, error) {
+	return json.Marshal(x)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var x T
+	err := json.Unmarshal(data, &x)
+	return x, err
+}
+
+// SpillConfig defines how much of a SpillBuffer is kept in memory and where the rest goes.
+type SpillConfig struct {
+	// Dir holds the segment files, empty uses os.TempDir
+	Dir string
+	// MemorySize is the number of elements kept in memory before spilling to disk, at least 1
+	MemorySize int
+	// SegmentSize is the size in bytes after which a new segment file is started, zero or less defaults to 64MiB
+	SegmentSize int64
+}
+
+func (c *SpillConfig) WithDir(dir string) SpillConfig {
+	return SpillConfig{
+		Dir:         dir,
+		MemorySize:  c.MemorySize,
+		SegmentSize: c.SegmentSize,
+	}
+}
+
+func (c *SpillConfig) WithMemorySize(memorySize int) SpillConfig {
+	return SpillConfig{
+		Dir:         c.Dir,
+		MemorySize:  memorySize,
+		SegmentSize: c.SegmentSize,
+	}
+}
+
+func (c *SpillConfig) WithSegmentSize(segmentSize int64) SpillConfig {
+	return SpillConfig{
+		Dir:         c.Dir,
+		MemorySize:  c.MemorySize,
+		SegmentSize: segmentSize,
+	}
+}
+
+const defaultSpillSegmentSize = 64 << 20
+
+// spillSegment is an append-only file of length-prefixed records, read back from the start while it is still being written
+type spillSegment struct {
+	path    string
+	writer  *os.File
+	reader  *os.File
+	buf     *bufio.Reader
+	size    int64
+	written int
+	read    int
+}
+
+func newSpillSegment(dir string) (*spillSegment, error) {
+	writer, err := os.CreateTemp(dir, "spill-*.segment")
+	if err != nil {
+		return nil, err
+	}
+	reader, err := os.Open(writer.Name())
+	if err != nil {
+		writer.Close()
+		os.Remove(writer.Name())
+		return nil, err
+	}
+	return &spillSegment{path: writer.Name(), writer: writer, reader: reader, buf: bufio.NewReader(reader)}, nil
+}
+
+func (s *spillSegment) append(data []byte) error {
+	record := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(data)), uint64(len(data)))
+	record = append(record, data...)
+	if _, err := s.writer.Write(record); err != nil {
+		return err
+	}
+	s.size += int64(len(record))
+	s.written++
+	return nil
+}
+
+func (s *spillSegment) next() ([]byte, error) {
+	length, err := binary.ReadUvarint(s.buf)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(s.buf, data); err != nil {
+		return nil, err
+	}
+	s.read++
+	return data, nil
+}
+
+func (s *spillSegment) remove() {
+	s.writer.Close()
+	s.reader.Close()
+	os.Remove(s.path)
+}
+
+// spillQueue is the on-disk tail of a SpillBuffer, a FIFO of segments removed as soon as they are read through
+type spillQueue struct {
+	dir         string
+	segmentSize int64
+	segments    []*spillSegment
+}
+
+func (q *spillQueue) empty() bool {
+	return len(q.segments) == 0
+}
+
+func (q *spillQueue) push(data []byte) error {
+	if q.empty() || q.segments[len(q.segments)-1].size >= q.segmentSize {
+		segment, err := newSpillSegment(q.dir)
+		if err != nil {
+			return err
+		}
+		q.segments = append(q.segments, segment)
+	}
+	return q.segments[len(q.segments)-1].append(data)
+}
+
+func (q *spillQueue) pop() ([]byte, error) {
+	head := q.segments[0]
+	data, err := head.next()
+	if err != nil {
+		return nil, err
+	}
+	if head.read == head.written {
+		head.remove()
+		q.segments[0] = nil
+		q.segments = q.segments[1:]
+	}
+	return data, nil
+}
+
+func (q *spillQueue) clear() {
+	for _, segment := range q.segments {
+		segment.remove()
+	}
+	q.segments = nil
+}
+
+// SpillBuffer buffers any number of elements between a fast producer and a slow consumer without blocking the producer,
+// keeping up to MemorySize elements in memory and spilling the rest to segment files encoded with the Codec.
+// The elements are emitted in FIFO order, each segment file is removed once read through and the remaining ones
+// are removed when the output closes. An element failing to decode is emitted as an error Result in its place,
+// an element failing to encode is emitted as an error Result ahead of the spilled elements,
+// and a failure to write or read the segments is emitted as an error Result closing the output, the rest of the source being drained.
+func SpillBuffer[T any](codec Codec[T], config SpillConfig) func(in <-chan T) <-chan Result[T] {
+	return SpillBufferCtx(context.Background(), codec, config)
+}
+
+// SpillBufferCtx works like SpillBuffer, closing the resulting channel on cancellation of the context.Context.
+func SpillBufferCtx[T any](ctx context.Context, codec Codec[T], config SpillConfig) func(in <-chan T) <-chan Result[T] {
+	memorySize := max(config.MemorySize, 1)
+	segmentSize := config.SegmentSize
+	if segmentSize <= 0 {
+		segmentSize = defaultSpillSegmentSize
+	}
+	return func(in <-chan T) <-chan Result[T] {
+		disk := &spillQueue{dir: config.Dir, segmentSize: segmentSize}
+		out := make(chan Result[T])
+		go func() {
+			defer close(out)
+			defer disk.clear()
+			var memory []Result[T]
+			source := in
+			// fail queues the error last and stops taking elements, dropping the spilled ones and draining the source
+			fail := func(err error) {
+				memory = append(memory, NewError[T](err))
+				if source != nil {
+					go drainCtx(ctx, source)
+					source = nil
+				}
+				disk.clear()
+			}
+			for source != nil || len(memory) > 0 || !disk.empty() {
+				// the memory holds the head of the buffer, so it is refilled from the disk first
+				for len(memory) < memorySize && !disk.empty() {
+					data, err := disk.pop()
+					if err != nil {
+						fail(err)
+						break
+					}
+					x, err := codec.Decode(data)
+					if err != nil {
+						memory = append(memory, NewError[T](err))
+						continue
+					}
+					memory = append(memory, NewResult(x))
+				}
+
+				var send chan Result[T]
+				var head Result[T]
+				if len(memory) > 0 {
+					send = out
+					head = memory[0]
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case send <- head:
+					memory[0] = Result[T]{}
+					memory = memory[1:]
+				case x, ok := <-source:
+					if !ok {
+						source = nil
+						continue
+					}
+					if len(memory) < memorySize && disk.empty() {
+						memory = append(memory, NewResult(x))
+						continue
+					}
+					data, err := codec.Encode(x)
+					if err != nil {
+						memory = append(memory, NewError[T](err))
+						continue
+					}
+					if err := disk.push(data); err != nil {
+						fail(err)
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
@@ -2,6 +2,7 @@ package chanstreaming
 
 import (
 	"context"
+	"sync"
 )
 
 // WithContext transforms the source channel to a channel that completes on cancellation of the context.Context
@@ -30,6 +31,7 @@ func WithContext[T any](ctx context.Context) func(in <-chan T) <-chan Result[T]
 }
 
 // ViaKillSwitch implements graceful termination on a signal received or channel closing from the `killSwitch` channel.
+// The rest of the source is left unread, see Detach for the variant that drains it.
 func ViaKillSwitch[T any, K any](killSwitch <-chan K) func(in <-chan T) <-chan T {
 	return func(in <-chan T) <-chan T {
 		output := make(chan T, 1)
@@ -54,13 +56,24 @@ func ViaKillSwitch[T any, K any](killSwitch <-chan K) func(in <-chan T) <-chan T
 
 // WhenDone creates a new channel that tracks source termination and invokes the `callback` when the source is done.
 func WhenDone[T any](callback func()) func(in <-chan T) <-chan T {
+	return WhenDoneCtx[T](context.Background(), callback)
+}
+
+// WhenDoneCtx works like WhenDone, also invoking the `callback` on cancellation of the context.Context.
+func WhenDoneCtx[T any](ctx context.Context, callback func()) func(in <-chan T) <-chan T {
 	return func(in <-chan T) <-chan T {
 		out := make(chan T, 1)
 		go func() {
 			defer callback()
 			defer close(out)
-			for x := range in {
-				out <- x
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if !sendCtx(ctx, out, x) {
+					return
+				}
 			}
 		}()
 
@@ -69,12 +82,104 @@ func WhenDone[T any](callback func()) func(in <-chan T) <-chan T {
 }
 
 // ToContext transforms the source channel to a context.Context. The context cancels on source channel closing.
+// The source elements are drained and discarded.
 func ToContext[T any](in <-chan T) context.Context {
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
-		<-in
+		Drain(in)
 		cancel()
 	}()
 
 	return ctx
 }
+
+// Drain consumes and discards the remaining elements of the source channel, blocking until it is closed.
+// Use it to release the producers of a stream that is no longer of interest.
+func Drain[T any](in <-chan T) {
+	for range in {
+	}
+}
+
+// drainCtx works like Drain, giving up on cancellation of the context.Context.
+func drainCtx[T any](ctx context.Context, in <-chan T) {
+	for {
+		if _, ok := recvCtx(ctx, in); !ok {
+			return
+		}
+	}
+}
+
+// DrainAsync works like Drain in a separate goroutine.
+// The resulting channel is closed once the source channel is closed.
+func DrainAsync[T any](in <-chan T) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Drain(in)
+	}()
+	return done
+}
+
+// Detach wraps the source channel so that its consumer can walk away at any moment.
+// Calling the returned `detach` function closes the resulting channel and drains the rest of the source
+// in background, releasing the upstream goroutines. It is safe to call `detach` more than once.
+func Detach[T any](in <-chan T) (<-chan T, func()) {
+	killSwitch := make(chan struct{})
+	var once sync.Once
+	detach := func() {
+		once.Do(func() { close(killSwitch) })
+	}
+
+	out := make(chan T, 1)
+	go func() {
+		// the source is drained after the resulting channel is closed
+		defer Drain(in)
+		defer close(out)
+		for {
+			select {
+			case <-killSwitch:
+				return
+			case data, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case <-killSwitch:
+					return
+				case out <- data:
+				}
+			}
+		}
+	}()
+
+	return out, detach
+}
+
+// sendCtx writes `v` into `out` unless the context.Context is cancelled first.
+// Reports whether the value was written.
+func sendCtx[T any](ctx context.Context, out chan<- T, v T) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- v:
+		return true
+	}
+}
+
+// recvCtx reads the next value from `in` unless the context.Context is cancelled first.
+// Reports false when the context is cancelled or `in` is closed.
+func recvCtx[T any](ctx context.Context, in <-chan T) (T, bool) {
+	var zero T
+	if ctx.Err() != nil {
+		return zero, false
+	}
+	select {
+	case <-ctx.Done():
+		return zero, false
+	case x, ok := <-in:
+		return x, ok
+	}
+}
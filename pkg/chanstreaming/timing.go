@@ -1,37 +1,69 @@
 package chanstreaming
 
 import (
+	"context"
 	"math/rand"
 	"time"
 )
 
-// Throttle limits the rate of data emitted from the source channel by aligning the source items with the `interval` ticker events.
+// Throttle limits the rate of data emitted from the source channel to one item per `interval`,
+// the first item being emitted `interval` after the start. Panics if `interval` is not positive.
+// See RateLimit for bursts, weighted items and limits shared across pipelines.
 func Throttle[T any](interval time.Duration) func(in <-chan T) <-chan T {
+	return ThrottleCtx[T](context.Background(), interval)
+}
+
+// ThrottleCtx works like Throttle, closing the resulting channel on cancellation of the context.Context.
+func ThrottleCtx[T any](ctx context.Context, interval time.Duration) func(in <-chan T) <-chan T {
+	validateRate(1, interval)
 	return func(in <-chan T) <-chan T {
-		out := make(chan T, 1)
-		go func() {
-			defer close(out)
-			throttle := time.Tick(interval)
-			for x := range in {
-				<-throttle
-				out <- x
-			}
-		}()
-		return out
+		limiter := NewLeakyBucket(1, interval).WithClock(ClockFromContext(ctx))
+		// the permit of the start is taken upfront, so the first item waits for `interval` like the following ones
+		limiter.Reserve(1)
+		return RateLimitCtx[T](ctx, limiter)(in)
 	}
 }
 
 // Jitter introduces random delays to the source channel items.
 func Jitter[T any](jitter time.Duration) func(in <-chan T) <-chan T {
+	return JitterCtx[T](context.Background(), jitter)
+}
+
+// JitterCtx works like Jitter, closing the resulting channel on cancellation of the context.Context.
+func JitterCtx[T any](ctx context.Context, jitter time.Duration) func(in <-chan T) <-chan T {
 	return func(in <-chan T) <-chan T {
 		out := make(chan T, 1)
 		go func() {
 			defer close(out)
-			for x := range in {
-				time.Sleep(time.Duration(float64(jitter) * (0.5 - rand.Float64())))
-				out <- x
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if !sleepCtx(ctx, time.Duration(float64(jitter)*(0.5-rand.Float64()))) {
+					return
+				}
+				if !sendCtx(ctx, out, x) {
+					return
+				}
 			}
 		}()
 		return out
 	}
 }
+
+// sleepCtx pauses for the duration `d` of the context.Context Clock unless the context.Context is cancelled first.
+// Reports whether the full duration has elapsed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := ClockFromContext(ctx).NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
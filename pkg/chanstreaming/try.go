@@ -0,0 +1,200 @@
+package chanstreaming
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorPolicy defines how the Try* operators treat the errors of a Result[T] stream.
+type ErrorPolicy int
+
+const (
+	ShortCircuit  ErrorPolicy = iota // this will emit the first error and stop, draining the rest of the source
+	CollectErrors                    // this will pass errors downstream and keep processing, TryFold joins all of them
+	SkipErrors                       // this will drop errors and keep processing
+)
+
+// AsResults lifts the source channel to a Result[T] channel to be processed with the Try* operators.
+func AsResults[T any](in <-chan T) <-chan Result[T] {
+	return Mapped[T, Result[T]](NewResult[T])(in)
+}
+
+// emitTry writes the Result into `out` as dictated by the ErrorPolicy.
+// Reports whether the processing should continue.
+func emitTry[T any](ctx context.Context, out chan<- Result[T], result Result[T], policy ErrorPolicy) bool {
+	if result.Error == nil {
+		return sendCtx(ctx, out, result)
+	}
+	switch policy {
+	case SkipErrors:
+		return true
+	case CollectErrors:
+		return sendCtx(ctx, out, result)
+	default:
+		sendCtx(ctx, out, result)
+		return false
+	}
+}
+
+// TryMap applies a fallible transformation function to each successful element of the source channel.
+// Errors from the source and from `fn`, panics included, are handled according to the ErrorPolicy.
+func TryMap[T any, R any](fn func(T) (R, error), policy ErrorPolicy) func(in <-chan Result[T]) <-chan Result[R] {
+	return TryMapCtx[T, R](context.Background(), fn, policy)
+}
+
+// TryMapCtx works like TryMap, closing the resulting channel on cancellation of the context.Context.
+func TryMapCtx[T any, R any](ctx context.Context, fn func(T) (R, error), policy ErrorPolicy) func(in <-chan Result[T]) <-chan Result[R] {
+	return func(in <-chan Result[T]) <-chan Result[R] {
+		out := make(chan Result[R], 1)
+		go func() {
+			// on short circuit the source is drained after the resulting channel is closed
+			defer drainCtx(ctx, in)
+			defer close(out)
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				result := NewError[R](x.Error)
+				if x.Error == nil {
+					data, err := tryCall(func() (R, error) { return fn(x.Data) })
+					result = Result[R]{Data: data, Error: err}
+				}
+				if !emitTry(ctx, out, result, policy) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// TryFilter filters the successful elements of the source channel based on a fallible `predicate` function.
+// Errors from the source and from `predicate`, panics included, are handled according to the ErrorPolicy.
+func TryFilter[T any](predicate func(T) (bool, error), policy ErrorPolicy) func(in <-chan Result[T]) <-chan Result[T] {
+	return TryFilterCtx[T](context.Background(), predicate, policy)
+}
+
+// TryFilterCtx works like TryFilter, closing the resulting channel on cancellation of the context.Context.
+func TryFilterCtx[T any](ctx context.Context, predicate func(T) (bool, error), policy ErrorPolicy) func(in <-chan Result[T]) <-chan Result[T] {
+	return func(in <-chan Result[T]) <-chan Result[T] {
+		out := make(chan Result[T], 1)
+		go func() {
+			defer drainCtx(ctx, in)
+			defer close(out)
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if x.Error == nil {
+					keep, err := tryCall(func() (bool, error) { return predicate(x.Data) })
+					if err == nil && !keep {
+						continue
+					}
+					x.Error = err
+				}
+				if !emitTry(ctx, out, x, policy) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// TryFlatMap expands each successful element of the source channel into a Result[R] channel.
+// Errors from the source, panics of `fn` and errors from the expanded channels are handled according to the ErrorPolicy.
+func TryFlatMap[T any, R any](fn func(T) <-chan Result[R], policy ErrorPolicy) func(in <-chan Result[T]) <-chan Result[R] {
+	return TryFlatMapCtx[T, R](context.Background(), fn, policy)
+}
+
+// TryFlatMapCtx works like TryFlatMap, closing the resulting channel on cancellation of the context.Context.
+func TryFlatMapCtx[T any, R any](ctx context.Context, fn func(T) <-chan Result[R], policy ErrorPolicy) func(in <-chan Result[T]) <-chan Result[R] {
+	return func(in <-chan Result[T]) <-chan Result[R] {
+		out := make(chan Result[R], 1)
+		go func() {
+			defer drainCtx(ctx, in)
+			defer close(out)
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					return
+				}
+				if x.Error != nil {
+					if !emitTry(ctx, out, NewError[R](x.Error), policy) {
+						return
+					}
+					continue
+				}
+				theChannel, err := tryCall(func() (<-chan Result[R], error) { return fn(x.Data), nil })
+				if err != nil {
+					if !emitTry(ctx, out, NewError[R](err), policy) {
+						return
+					}
+					continue
+				}
+				for {
+					result, ok := recvCtx(ctx, theChannel)
+					if !ok {
+						break
+					}
+					if !emitTry(ctx, out, result, policy) {
+						drainCtx(ctx, theChannel)
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// TryFold folds the successful elements of the source channel with a fallible `fn` function, treating panics as errors.
+// With ShortCircuit the first error is emitted instead of the state, with CollectErrors the final state
+// is emitted along with all the errors joined, with SkipErrors the errors are ignored.
+func TryFold[TIn any, TState any](fn func(TState, TIn) (TState, error), initialState TState, policy ErrorPolicy) func(in <-chan Result[TIn]) <-chan Result[TState] {
+	return TryFoldCtx[TIn, TState](context.Background(), fn, initialState, policy)
+}
+
+// TryFoldCtx works like TryFold. On cancellation of the context.Context the resulting channel is closed
+// without emitting the accumulated state.
+func TryFoldCtx[TIn any, TState any](ctx context.Context, fn func(TState, TIn) (TState, error), initialState TState, policy ErrorPolicy) func(in <-chan Result[TIn]) <-chan Result[TState] {
+	return func(in <-chan Result[TIn]) <-chan Result[TState] {
+		out := make(chan Result[TState], 1)
+		go func() {
+			defer drainCtx(ctx, in)
+			defer close(out)
+			state := initialState
+			var errs []error
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					break
+				}
+				err := x.Error
+				if err == nil {
+					var newState TState
+					newState, err = tryCall(func() (TState, error) { return fn(state, x.Data) })
+					if err == nil {
+						state = newState
+						continue
+					}
+				}
+				switch policy {
+				case SkipErrors:
+				case CollectErrors:
+					errs = append(errs, err)
+				default:
+					sendCtx(ctx, out, NewError[TState](err))
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			out <- Result[TState]{Data: state, Error: errors.Join(errs...)}
+		}()
+		return out
+	}
+}
@@ -1,9 +1,6 @@
 package chanstreaming
 
-import (
-	"errors"
-	"fmt"
-)
+import "context"
 
 // UnfoldSafe creates a channel that unfolds the state into data elements asynchronously.
 // returns a tuple of State, Data, Continued
@@ -11,18 +8,17 @@ import (
 // state1 -> state2, data1, true => continue unfolding, emit data1
 // state2 -> _, _, false => stop unfolding
 func UnfoldSafe[TState any, TData any](fn func(s TState) (TState, TData, bool), zero TState) <-chan Result[TData] {
+	return UnfoldSafeCtx[TState, TData](context.Background(), fn, zero)
+}
+
+// UnfoldSafeCtx works like UnfoldSafe, stopping the unfolding on cancellation of the context.Context.
+func UnfoldSafeCtx[TState any, TData any](ctx context.Context, fn func(s TState) (TState, TData, bool), zero TState) <-chan Result[TData] {
 	output := make(chan Result[TData], 1)
 	// launch worker goroutine
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				// check if the panic is an error
-				if _, ok := r.(error); ok {
-					output <- Result[TData]{Error: r.(error)}
-				} else {
-					// convert panic to error message str
-					output <- Result[TData]{Error: errors.New(fmt.Sprint(r))}
-				}
+				sendCtx(ctx, output, Result[TData]{Error: panicToError(r)})
 			}
 			close(output)
 		}()
@@ -33,7 +29,9 @@ func UnfoldSafe[TState any, TData any](fn func(s TState) (TState, TData, bool),
 			if !continued {
 				break
 			} else {
-				output <- Result[TData]{Data: data}
+				if !sendCtx(ctx, output, Result[TData]{Data: data}) {
+					break
+				}
 				st = newSt
 			}
 		}
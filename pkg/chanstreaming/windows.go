@@ -0,0 +1,249 @@
+package chanstreaming
+
+import (
+	"context"
+	"time"
+)
+
+// WindowAggregate is the result of an Aggregator over a closed window.
+type WindowAggregate[R any] struct {
+	Start  time.Time
+	End    time.Time
+	Count  int
+	Result R
+}
+
+func aggregateToWindow[T any](w WindowAggregate[[]T]) Window[T] {
+	return Window[T]{Start: w.Start, End: w.End, Elements: w.Result}
+}
+
+// timeWindowsAggregateCtx aggregates the source elements into processing-time windows of `size` ending every `slide`.
+func timeWindowsAggregateCtx[T any, R any](ctx context.Context, size time.Duration, slide time.Duration, newAggregator func() Aggregator[T, R]) func(in <-chan T) <-chan WindowAggregate[R] {
+	if size <= 0 || slide <= 0 {
+		panic("window size and slide must be positive")
+	}
+	return func(in <-chan T) <-chan WindowAggregate[R] {
+		out := make(chan WindowAggregate[R], 1)
+		go func() {
+			defer close(out)
+			clock := ClockFromContext(ctx)
+			// windows end on the multiples of `slide`, the current one spans [end-size, end)
+			end := clock.Now().Truncate(slide).Add(slide)
+			timer := clock.NewTimer(end.Sub(clock.Now()))
+			defer func() { timer.Stop() }()
+
+			aggregator := newAggregator()
+			count := 0
+			// the elements are only kept for overlapping windows, to remove them from the aggregator once they leave the window
+			overlapping := slide < size
+			var buffer []timedWindowElement[T]
+
+			emit := func() bool {
+				if count == 0 {
+					return true
+				}
+				return sendCtx(ctx, out, WindowAggregate[R]{Start: end.Add(-size), End: end, Count: count, Result: aggregator.Result()})
+			}
+			// advance closes the windows ending by `now`
+			advance := func(now time.Time) bool {
+				if now.Before(end) {
+					return true
+				}
+				for !now.Before(end) {
+					if !emit() {
+						return false
+					}
+					end = end.Add(slide)
+					if !overlapping {
+						aggregator = newAggregator()
+						count = 0
+						continue
+					}
+					start := end.Add(-size)
+					dropCount := 0
+					for dropCount < len(buffer) && buffer[dropCount].ts.Before(start) {
+						aggregator.Remove(buffer[dropCount].data)
+						dropCount++
+					}
+					buffer = buffer[dropCount:]
+					count -= dropCount
+				}
+				timer.Stop()
+				timer = clock.NewTimer(end.Sub(now))
+				return true
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case now := <-timer.C():
+					if !advance(maxTime(now, clock.Now())) {
+						return
+					}
+				case x, ok := <-in:
+					if !ok {
+						emit()
+						return
+					}
+					now := clock.Now()
+					if !advance(now) {
+						return
+					}
+					if now.Before(end.Add(-size)) {
+						// falls between the windows when `slide` exceeds `size`
+						continue
+					}
+					aggregator.Add(x)
+					count++
+					if overlapping {
+						buffer = append(buffer, timedWindowElement[T]{data: x, ts: now})
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// countWindowsAggregateCtx aggregates the source elements into windows of `size` elements starting every `slide` elements.
+func countWindowsAggregateCtx[T any, R any](ctx context.Context, size int, slide int, newAggregator func() Aggregator[T, R]) func(in <-chan T) <-chan WindowAggregate[R] {
+	if size <= 0 || slide <= 0 {
+		panic("window size and slide must be positive")
+	}
+	return func(in <-chan T) <-chan WindowAggregate[R] {
+		out := make(chan WindowAggregate[R], 1)
+		go func() {
+			defer close(out)
+			clock := ClockFromContext(ctx)
+			aggregator := newAggregator()
+			var buffer []timedWindowElement[T]
+			// fresh is the number of buffered elements not emitted in any window yet
+			fresh := 0
+			skip := 0
+
+			emit := func() bool {
+				return sendCtx(ctx, out, WindowAggregate[R]{
+					Start:  buffer[0].ts,
+					End:    buffer[len(buffer)-1].ts,
+					Count:  len(buffer),
+					Result: aggregator.Result(),
+				})
+			}
+
+			for {
+				x, ok := recvCtx(ctx, in)
+				if !ok {
+					if ctx.Err() == nil && fresh > 0 {
+						emit()
+					}
+					return
+				}
+				if skip > 0 {
+					// falls between the windows when `slide` exceeds `size`
+					skip--
+					continue
+				}
+				aggregator.Add(x)
+				buffer = append(buffer, timedWindowElement[T]{data: x, ts: clock.Now()})
+				fresh++
+				if len(buffer) < size {
+					continue
+				}
+				if !emit() {
+					return
+				}
+				fresh = 0
+				if slide >= size {
+					aggregator = newAggregator()
+					buffer = buffer[:0]
+					skip = slide - size
+					continue
+				}
+				for _, e := range buffer[:slide] {
+					aggregator.Remove(e.data)
+				}
+				buffer = buffer[slide:]
+			}
+		}()
+		return out
+	}
+}
+
+// TumblingWindow groups the source elements into consecutive non-overlapping windows of `size` by their arrival time,
+// emitting each window once it closes. Windows are aligned to the multiples of `size`, empty windows are not emitted.
+// Panics if `size` is not positive.
+func TumblingWindow[T any](size time.Duration) func(in <-chan T) <-chan Window[T] {
+	return TumblingWindowCtx[T](context.Background(), size)
+}
+
+// TumblingWindowCtx works like TumblingWindow, closing the resulting channel on cancellation of the context.Context.
+func TumblingWindowCtx[T any](ctx context.Context, size time.Duration) func(in <-chan T) <-chan Window[T] {
+	return HoppingWindowCtx[T](ctx, size, size)
+}
+
+// HoppingWindow groups the source elements into windows of `size` ending every `slide` by their arrival time,
+// emitting each window once it closes. Windows are aligned to the multiples of `slide`, empty windows are not emitted.
+// Panics if `size` or `slide` is not positive.
+func HoppingWindow[T any](size time.Duration, slide time.Duration) func(in <-chan T) <-chan Window[T] {
+	return HoppingWindowCtx[T](context.Background(), size, slide)
+}
+
+// HoppingWindowCtx works like HoppingWindow, closing the resulting channel on cancellation of the context.Context.
+func HoppingWindowCtx[T any](ctx context.Context, size time.Duration, slide time.Duration) func(in <-chan T) <-chan Window[T] {
+	aggregate := HoppingWindowAggregateCtx[T, []T](ctx, size, slide, newCollectAggregator[T])
+	return func(in <-chan T) <-chan Window[T] {
+		windows := aggregate(in)
+		return MappedCtx[WindowAggregate[[]T], Window[T]](ctx, aggregateToWindow[T])(windows)
+	}
+}
+
+// CountWindow groups the source elements into windows of `size` elements starting every `slide` elements,
+// the window Start and End being the arrival times of its first and last elements.
+// The last window may be shorter, it is emitted when the source closes if it holds elements not emitted yet.
+// Panics if `size` or `slide` is not positive.
+func CountWindow[T any](size int, slide int) func(in <-chan T) <-chan Window[T] {
+	return CountWindowCtx[T](context.Background(), size, slide)
+}
+
+// CountWindowCtx works like CountWindow, closing the resulting channel on cancellation of the context.Context.
+func CountWindowCtx[T any](ctx context.Context, size int, slide int) func(in <-chan T) <-chan Window[T] {
+	aggregate := CountWindowAggregateCtx[T, []T](ctx, size, slide, newCollectAggregator[T])
+	return func(in <-chan T) <-chan Window[T] {
+		windows := aggregate(in)
+		return MappedCtx[WindowAggregate[[]T], Window[T]](ctx, aggregateToWindow[T])(windows)
+	}
+}
+
+// TumblingWindowAggregate works like TumblingWindow, emitting the result of an Aggregator created with `newAggregator`
+// for each window instead of its elements.
+func TumblingWindowAggregate[T any, R any](size time.Duration, newAggregator func() Aggregator[T, R]) func(in <-chan T) <-chan WindowAggregate[R] {
+	return TumblingWindowAggregateCtx[T, R](context.Background(), size, newAggregator)
+}
+
+// TumblingWindowAggregateCtx works like TumblingWindowAggregate, closing the resulting channel on cancellation of the context.Context.
+func TumblingWindowAggregateCtx[T any, R any](ctx context.Context, size time.Duration, newAggregator func() Aggregator[T, R]) func(in <-chan T) <-chan WindowAggregate[R] {
+	return timeWindowsAggregateCtx[T, R](ctx, size, size, newAggregator)
+}
+
+// HoppingWindowAggregate works like HoppingWindow, emitting the result of an Aggregator for each window instead of its elements.
+// A single Aggregator created with `newAggregator` slides along with the windows, the elements leaving a window are removed from it.
+func HoppingWindowAggregate[T any, R any](size time.Duration, slide time.Duration, newAggregator func() Aggregator[T, R]) func(in <-chan T) <-chan WindowAggregate[R] {
+	return HoppingWindowAggregateCtx[T, R](context.Background(), size, slide, newAggregator)
+}
+
+// HoppingWindowAggregateCtx works like HoppingWindowAggregate, closing the resulting channel on cancellation of the context.Context.
+func HoppingWindowAggregateCtx[T any, R any](ctx context.Context, size time.Duration, slide time.Duration, newAggregator func() Aggregator[T, R]) func(in <-chan T) <-chan WindowAggregate[R] {
+	return timeWindowsAggregateCtx[T, R](ctx, size, slide, newAggregator)
+}
+
+// CountWindowAggregate works like CountWindow, emitting the result of an Aggregator for each window instead of its elements.
+// A single Aggregator created with `newAggregator` slides along with the windows, the elements leaving a window are removed from it.
+func CountWindowAggregate[T any, R any](size int, slide int, newAggregator func() Aggregator[T, R]) func(in <-chan T) <-chan WindowAggregate[R] {
+	return CountWindowAggregateCtx[T, R](context.Background(), size, slide, newAggregator)
+}
+
+// CountWindowAggregateCtx works like CountWindowAggregate, closing the resulting channel on cancellation of the context.Context.
+func CountWindowAggregateCtx[T any, R any](ctx context.Context, size int, slide int, newAggregator func() Aggregator[T, R]) func(in <-chan T) <-chan WindowAggregate[R] {
+	return countWindowsAggregateCtx[T, R](ctx, size, slide, newAggregator)
+}
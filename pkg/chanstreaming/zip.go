@@ -0,0 +1,215 @@
+package chanstreaming
+
+import "context"
+
+// Pair holds the elements combined from two channels.
+type Pair[A any, B any] struct {
+	Left  A
+	Right B
+}
+
+type ZipMode int
+
+const (
+	ZipShortest ZipMode = iota // completes as soon as either source completes, draining the other one
+	ZipLongest                 // completes once both sources complete, padding the completed source with zero values
+)
+
+// Zip pairs the elements of the left and right channels by their position.
+func Zip[A any, B any](mode ZipMode) func(left <-chan A, right <-chan B) <-chan Pair[A, B] {
+	return ZipCtx[A, B](context.Background(), mode)
+}
+
+// ZipCtx works like Zip, closing the resulting channel on cancellation of the context.Context.
+func ZipCtx[A any, B any](ctx context.Context, mode ZipMode) func(left <-chan A, right <-chan B) <-chan Pair[A, B] {
+	return ZipWithCtx[A, B, Pair[A, B]](ctx, func(a A, b B) Pair[A, B] {
+		return Pair[A, B]{Left: a, Right: b}
+	}, mode)
+}
+
+// ZipWith combines the elements of the left and right channels by their position with `fn`.
+func ZipWith[A any, B any, R any](fn func(A, B) R, mode ZipMode) func(left <-chan A, right <-chan B) <-chan R {
+	return ZipWithCtx[A, B, R](context.Background(), fn, mode)
+}
+
+// ZipWithCtx works like ZipWith, closing the resulting channel on cancellation of the context.Context.
+func ZipWithCtx[A any, B any, R any](ctx context.Context, fn func(A, B) R, mode ZipMode) func(left <-chan A, right <-chan B) <-chan R {
+	return func(left <-chan A, right <-chan B) <-chan R {
+		out := make(chan R, 1)
+		go func() {
+			defer close(out)
+			leftDone, rightDone := false, false
+			for {
+				var a A
+				var b B
+				if !leftDone {
+					a, leftDone = recvDone(ctx, left)
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				if !(leftDone && mode == ZipShortest) && !rightDone {
+					b, rightDone = recvDone(ctx, right)
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				if mode == ZipShortest && (leftDone || rightDone) {
+					if !leftDone {
+						go drainCtx(ctx, left)
+					}
+					if !rightDone {
+						go drainCtx(ctx, right)
+					}
+					return
+				}
+				if leftDone && rightDone {
+					return
+				}
+				if !sendCtx(ctx, out, fn(a, b)) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// recvDone works like recvCtx, reporting whether the source is complete rather than whether an element was received.
+func recvDone[T any](ctx context.Context, in <-chan T) (T, bool) {
+	x, ok := recvCtx(ctx, in)
+	return x, !ok
+}
+
+// ZipLatest pairs the latest elements of the left and right channels, emitting a Pair on each element
+// once both sources have emitted. It completes once both sources complete.
+func ZipLatest[A any, B any]() func(left <-chan A, right <-chan B) <-chan Pair[A, B] {
+	return ZipLatestCtx[A, B](context.Background())
+}
+
+// ZipLatestCtx works like ZipLatest, closing the resulting channel on cancellation of the context.Context.
+func ZipLatestCtx[A any, B any](ctx context.Context) func(left <-chan A, right <-chan B) <-chan Pair[A, B] {
+	return func(left <-chan A, right <-chan B) <-chan Pair[A, B] {
+		out := make(chan Pair[A, B], 1)
+		go func() {
+			defer close(out)
+			latest := Pair[A, B]{}
+			hasLeft, hasRight := false, false
+			for left != nil || right != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case a, ok := <-left:
+					if !ok {
+						left = nil
+						continue
+					}
+					latest.Left, hasLeft = a, true
+				case b, ok := <-right:
+					if !ok {
+						right = nil
+						continue
+					}
+					latest.Right, hasRight = b, true
+				}
+				if hasLeft && hasRight && !sendCtx(ctx, out, latest) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// indexedElement is an element of one of the sources of CombineLatest
+type indexedElement[T any] struct {
+	index int
+	data  T
+}
+
+// CombineLatest emits the latest elements of all the sources on each element, once every source has emitted.
+// It completes once all the sources complete.
+func CombineLatest[T any](sources ...<-chan T) <-chan []T {
+	return CombineLatestCtx(context.Background(), sources...)
+}
+
+// CombineLatestCtx works like CombineLatest, closing the resulting channel on cancellation of the context.Context.
+func CombineLatestCtx[T any](ctx context.Context, sources ...<-chan T) <-chan []T {
+	indexed := make([]<-chan indexedElement[T], len(sources))
+	for i, source := range sources {
+		indexed[i] = MappedCtx(ctx, func(x T) indexedElement[T] {
+			return indexedElement[T]{index: i, data: x}
+		})(source)
+	}
+	merged := MergeCtx(ctx, indexed)
+
+	out := make(chan []T, 1)
+	go func() {
+		defer close(out)
+		latest := make([]T, len(sources))
+		seen := make([]bool, len(sources))
+		seenCount := 0
+		for {
+			x, ok := recvCtx(ctx, merged)
+			if !ok {
+				return
+			}
+			latest[x.index] = x.data
+			if !seen[x.index] {
+				seen[x.index] = true
+				seenCount++
+			}
+			if seenCount < len(sources) {
+				continue
+			}
+			combined := make([]T, len(latest))
+			copy(combined, latest)
+			if !sendCtx(ctx, out, combined) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// WithLatestFrom pairs each source element with the latest element of the `side` channel,
+// dropping the source elements that arrive before the first side element.
+// It completes with the source, draining the rest of the side channel.
+func WithLatestFrom[T any, S any](side <-chan S) func(in <-chan T) <-chan Pair[T, S] {
+	return WithLatestFromCtx[T, S](context.Background(), side)
+}
+
+// WithLatestFromCtx works like WithLatestFrom, closing the resulting channel on cancellation of the context.Context.
+func WithLatestFromCtx[T any, S any](ctx context.Context, side <-chan S) func(in <-chan T) <-chan Pair[T, S] {
+	return func(in <-chan T) <-chan Pair[T, S] {
+		out := make(chan Pair[T, S], 1)
+		go func() {
+			defer close(out)
+			var latest S
+			hasLatest := false
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case s, ok := <-side:
+					if !ok {
+						side = nil
+						continue
+					}
+					latest, hasLatest = s, true
+				case x, ok := <-in:
+					if !ok {
+						if side != nil {
+							go drainCtx(ctx, side)
+						}
+						return
+					}
+					if hasLatest && !sendCtx(ctx, out, Pair[T, S]{Left: x, Right: latest}) {
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
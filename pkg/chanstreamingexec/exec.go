@@ -147,13 +147,15 @@ func NewStdinPipeSink(cmd *exec.Cmd, onWriteError WriteErrorCallback) (func(src
 			for len(toWrite) > 0 {
 				written, writeErr := stdInPipe.Write(toWrite)
 				if writeErr != nil {
+					// the pipe is broken, e.g. the process has exited, the rest of the item is dropped
 					onWriteError(writeErr)
+					return
 				}
-				if written > 0 {
-					toWrite = toWrite[written:]
-				} else {
-					onWriteError(nil)
+				if written == 0 {
+					onWriteError(io.ErrShortWrite)
+					return
 				}
+				toWrite = toWrite[written:]
 			}
 		}
 	}
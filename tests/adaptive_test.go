@@ -0,0 +1,108 @@
+package chanstreamingtests_test
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveLimiterAIMD(t *testing.T) {
+	limiter := ch.NewAdaptiveLimiter(ch.AdaptiveLimiterConfig{
+		Algorithm:        ch.AIMD,
+		InitialLimit:     4,
+		MaxLimit:         8,
+		LatencyThreshold: 100 * time.Millisecond,
+	})
+	assert.Equal(t, 4, limiter.Limit())
+
+	limiter.Record(10*time.Millisecond, false)
+	limiter.Record(10*time.Millisecond, false)
+	assert.Equal(t, 6, limiter.Limit())
+	// 6 * 0.9
+	limiter.Record(10*time.Millisecond, true)
+	assert.Equal(t, 5, limiter.Limit())
+	// slow calls back off like failures, 5.4 * 0.9
+	limiter.Record(time.Second, false)
+	assert.Equal(t, 4, limiter.Limit())
+
+	for i := 0; i < 10; i++ {
+		limiter.Record(10*time.Millisecond, false)
+	}
+	assert.Equal(t, 8, limiter.Limit())
+}
+
+func TestAdaptiveLimiterGradient(t *testing.T) {
+	limiter := ch.NewAdaptiveLimiter(ch.AdaptiveLimiterConfig{Algorithm: ch.Gradient, InitialLimit: 10, MaxLimit: 100})
+	for i := 0; i < 10; i++ {
+		limiter.Record(10*time.Millisecond, false)
+	}
+	grown := limiter.Limit()
+	assert.Greater(t, grown, 10)
+
+	// latencies well above the lowest observed one shrink the limit
+	for i := 0; i < 10; i++ {
+		limiter.Record(100*time.Millisecond, false)
+	}
+	assert.Less(t, limiter.Limit(), grown)
+}
+
+var errOddInput = errors.New("odd input")
+
+func TestMapAdaptive(t *testing.T) {
+	limiter := ch.NewAdaptiveLimiter(ch.AdaptiveLimiterConfig{InitialLimit: 2, MinLimit: 2, MaxLimit: 2})
+	var running, maxRunning atomic.Int32
+	fn := func(x int) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		if x%2 == 1 {
+			return 0, errOddInput
+		}
+		return x * 10, nil
+	}
+
+	results := ch.ToSlice(ch.MapAdaptive(fn, limiter)(ch.FromSlice([]int{1, 2, 3, 4, 5, 6})))
+	assert.Len(t, results, 6)
+	for i, r := range results {
+		if i%2 == 0 {
+			assert.ErrorIs(t, r.Error, errOddInput)
+		} else {
+			assert.Equal(t, ch.NewResult((i+1)*10), r)
+		}
+	}
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	assert.Equal(t, 0, limiter.InFlight())
+}
+
+func TestMapUnorderedAdaptive(t *testing.T) {
+	limiter := ch.NewAdaptiveLimiter(ch.AdaptiveLimiterConfig{InitialLimit: 4})
+	double := func(x int) (int, error) { return x * 2, nil }
+	var data []int
+	for r := range ch.MapUnorderedAdaptive(double, limiter)(ch.FromSlice([]int{1, 2, 3, 4, 5})) {
+		assert.NoError(t, r.Error)
+		data = append(data, r.Data)
+	}
+	sort.Ints(data)
+	assert.Equal(t, []int{2, 4, 6, 8, 10}, data)
+	// every fast success raises the limit
+	assert.Equal(t, 9, limiter.Limit())
+}
+
+func TestMapAdaptiveBacksOff(t *testing.T) {
+	limiter := ch.NewAdaptiveLimiter(ch.AdaptiveLimiterConfig{InitialLimit: 8})
+	failing := func(x int) (int, error) { return 0, errOddInput }
+	results := ch.ToSlice(ch.MapUnorderedAdaptive(failing, limiter)(ch.FromSlice(make([]int, 30))))
+	assert.Len(t, results, 30)
+	assert.Equal(t, 1, limiter.Limit())
+}
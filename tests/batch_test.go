@@ -1,6 +1,7 @@
 package chanstreamingtests_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -9,15 +10,17 @@ import (
 )
 
 func TestBatch(t *testing.T) {
+	// the clock never moves, so the batches are cut by size only
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
 	source := make(chan int, 10)
 	go func() {
 		defer close(source)
 		for i := 1; i <= 10; i++ {
 			source <- i
-			time.Sleep(time.Millisecond * 10) // Simulate input delay
 		}
 	}()
-	batched := ch.Batch[int](3, 50*time.Millisecond)(source)
+	batched := ch.BatchCtx[int](ctx, 3, 50*time.Millisecond)(source)
 
 	result := ch.ToSlice(batched)
 	expected := [][]int{
@@ -29,26 +32,83 @@ func TestBatch(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
+func TestBatchFlushesOnInterval(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan int)
+	batched := ch.BatchCtx[int](ctx, 10, 50*time.Millisecond)(source)
+
+	clock.BlockUntil(1)
+	source <- 1
+	source <- 2
+	clock.Advance(60 * time.Millisecond)
+
+	// the interval elapsed with the source still open, element 1 is guaranteed to be ahead of the tick
+	batch := <-batched
+	assert.Equal(t, 1, batch[0])
+	assert.LessOrEqual(t, len(batch), 2)
+
+	close(source)
+	for rest := range batched {
+		batch = append(batch, rest...)
+	}
+	assert.Equal(t, []int{1, 2}, batch)
+}
+
+// tickObservingClock hands the times read by the batching over to the test waiting for them,
+// so that the test advances the clock only once a tick is handled.
+// The times are buffered, the ones read before the tick the test waits for are skipped.
+type tickObservingClock struct {
+	*ch.ManualClock
+	observed chan time.Time
+}
+
+func (c *tickObservingClock) Now() time.Time {
+	now := c.ManualClock.Now()
+	select {
+	case c.observed <- now:
+	default:
+	}
+	return now
+}
+
+// advanceTicking advances the clock to `target` tick by tick, waiting for each tick to be handled
+func (c *tickObservingClock) advanceTicking(target time.Time, nextTick *time.Time, tickSize time.Duration) {
+	for !nextTick.After(target) {
+		c.Advance(nextTick.Sub(c.ManualClock.Now()))
+		for observed := range c.observed {
+			if observed.Equal(*nextTick) {
+				break
+			}
+		}
+		*nextTick = nextTick.Add(tickSize)
+	}
+	c.Advance(target.Sub(c.ManualClock.Now()))
+}
+
 func TestBatchWithDecreasingFrequency(t *testing.T) {
-	source := make(chan int, 1) // Large buffer to prevent blocking
+	start := time.Unix(0, 0)
+	clock := &tickObservingClock{ManualClock: ch.NewManualClock(start), observed: make(chan time.Time, 16)}
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan int)
 	flushInterval := 200 * time.Millisecond
+	// the batching checks the interval on ticks of 5 milliseconds
+	tickSize := 5 * time.Millisecond
 	batchSize := 100
 
 	// Start batched processing
-	batched := ch.Batch[int](batchSize, flushInterval)(source)
+	batched := ch.BatchCtx[int](ctx, batchSize, flushInterval)(source)
 	buffered := ch.Buffered[[]int](1000)(batched)
 	logged := ch.Apply(func(x []int) { t.Log("Batch received:", len(x)) })(buffered)
 
-	// Start measuring time
-	start := time.Now()
 	// Source emits data with increasing intervals between elements
+	clock.BlockUntil(1)
 	go func() {
 		defer close(source)
+		at, nextTick := start, start.Add(tickSize)
 		for i := range 400 {
-			sleepTime := i / 20
-			if sleepTime > 0 {
-				time.Sleep(time.Millisecond * time.Duration(sleepTime))
-			}
+			at = at.Add(time.Millisecond * time.Duration(i/20))
+			clock.advanceTicking(at, &nextTick, tickSize)
 			source <- i
 		}
 	}()
@@ -66,41 +126,37 @@ func TestBatchWithDecreasingFrequency(t *testing.T) {
 		minBatchSize = min(minBatchSize, currentBatchSize)
 	}
 
-	t.Log("Batch test passed:", len(results), "batches emitted in", time.Since(start))
+	t.Log("Batch test passed:", len(results), "batches emitted in", clock.ManualClock.Now().Sub(start))
 }
 
-// Generates 8M events and sends them into a channel at controlled speed
-func generateHighThroughputSource(ratePerSecond int, size int) <-chan int {
-	maxSleepTime := 100 * time.Millisecond
-	itemsPerMaxSleepTime := ratePerSecond * (int(time.Second) / int(maxSleepTime))
+// Generates `size` events at `ratePerSecond`, advancing the clock by the time each chunk of them takes
+func generateHighThroughputSource(clock *ch.ManualClock, ratePerSecond int, size int) <-chan int {
+	chunkInterval := 100 * time.Millisecond
+	itemsPerChunk := ratePerSecond / int(time.Second/chunkInterval)
 	out := make(chan int, min(10000, ratePerSecond)) // Buffered with 10k for smooth flow
 	go func() {
 		defer close(out)
-		nextWrite := time.Now()
 		for i := range size {
-			out <- i
-			if i%itemsPerMaxSleepTime == 0 {
-				nextWrite = nextWrite.Add(maxSleepTime)
-				sleepDuration := time.Until(nextWrite)
-				if sleepDuration > 0 {
-					time.Sleep(sleepDuration)
-				}
+			if i > 0 && i%itemsPerChunk == 0 {
+				clock.Advance(chunkInterval)
 			}
+			out <- i
 		}
 	}()
 	return out
 }
 
 // Test Batch Processing at High Throughput, piping 8M events through a noop batch processor and measuring throughput.
-// Should process 8M events in 20000 batches in around 4 seconds.
+// The 8M events span 400 seconds of the clock, cut into batches by the flush interval.
 func TestBatchWithHighThroughput(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
 	totalSize := 8000000
 	batchSize := 20000
-	source := generateHighThroughputSource(batchSize, totalSize)
 	flushInterval := 34 * time.Millisecond
 
 	// Start batched processing
-	batched := ch.Batch[int](batchSize, flushInterval)(source)
+	batched := ch.BatchCtx[int](ctx, batchSize, flushInterval)(generateHighThroughputSource(clock, batchSize, totalSize))
 
 	// Track processing time
 	start := time.Now()
@@ -109,6 +165,7 @@ func TestBatchWithHighThroughput(t *testing.T) {
 
 	// Process batches
 	for batch := range batched {
+		assert.LessOrEqual(t, len(batch), batchSize)
 		totalEvents += len(batch)
 		batchCount++
 	}
@@ -119,3 +176,19 @@ func TestBatchWithHighThroughput(t *testing.T) {
 	assert.Equal(t, totalSize, totalEvents)
 	t.Log("Processed", totalEvents, "events in", batchCount, "batches over", elapsed)
 }
+
+func TestBatchCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	source := make(chan int)
+	batched := ch.BatchCtx[int](ctx, 2, time.Hour)(source)
+
+	source <- 1
+	source <- 2
+	assert.Equal(t, []int{1, 2}, <-batched)
+	source <- 3
+	cancel()
+
+	// the pending batch is discarded and the output closes while the source is still open
+	result := ch.ToSlice(batched)
+	assert.Empty(t, result)
+}
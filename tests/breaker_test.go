@@ -0,0 +1,79 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	breaker := ch.NewCircuitBreaker(ch.CircuitBreakerConfig{
+		ConsecutiveFailures: 2,
+		CoolDown:            50 * time.Millisecond,
+		Clock:               clock,
+	})
+	changes := breaker.StateChanges(ctx, 10)
+
+	healthy := false
+	calls := 0
+	// the calls go through the breaker one at a time, so each outcome is recorded before the next call
+	call := ch.Mapped(func(i int) ch.Result[int] {
+		return ch.CallCircuitBreaker(breaker, func() (int, error) {
+			calls++
+			if !healthy {
+				return 0, errors.New("down")
+			}
+			return i, nil
+		})
+	})
+
+	results := ch.ToSlice(call(ch.FromSlice([]int{1, 2, 3, 4})))
+	assert.Equal(t, 2, calls)
+	assert.ErrorIs(t, results[2].Error, ch.ErrCircuitOpen)
+	assert.ErrorIs(t, results[3].Error, ch.ErrCircuitOpen)
+	assert.Equal(t, ch.CircuitOpen, breaker.State())
+
+	healthy = true
+	clock.Advance(60 * time.Millisecond)
+	results = ch.ToSlice(call(ch.FromSlice([]int{5, 6})))
+	assert.Equal(t, []int{5, 6}, ch.ToSlice(ch.Panic(ch.FromSlice(results))))
+	assert.Equal(t, ch.CircuitClosed, breaker.State())
+
+	cancel()
+	transitions := []ch.CircuitState{}
+	for change := range changes {
+		transitions = append(transitions, change.To)
+	}
+	assert.Equal(t, []ch.CircuitState{ch.CircuitOpen, ch.CircuitHalfOpen, ch.CircuitClosed}, transitions)
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	breaker := ch.NewCircuitBreaker(ch.CircuitBreakerConfig{
+		FailureRate: 0.5,
+		Window:      4,
+		CoolDown:    time.Hour,
+	})
+
+	outcomes := []error{nil, errors.New("1"), nil, nil, errors.New("2"), errors.New("3")}
+	for _, outcome := range outcomes {
+		ch.CallCircuitBreaker(breaker, func() (int, error) { return 0, outcome })
+	}
+
+	// the last 4 calls hold 2 failures
+	assert.Equal(t, ch.CircuitOpen, breaker.State())
+}
+
+func TestMapCircuitBreaker(t *testing.T) {
+	breaker := ch.NewCircuitBreaker(ch.CircuitBreakerConfig{ConsecutiveFailures: 2, CoolDown: time.Hour})
+	double := func(x int) (int, error) { return x * 2, nil }
+	results := ch.ToSlice(ch.MapCircuitBreaker(breaker, double, 4)(ch.FromSlice([]int{1, 2, 3})))
+	assert.Equal(t, []ch.Result[int]{ch.NewResult(2), ch.NewResult(4), ch.NewResult(6)}, results)
+	assert.Equal(t, ch.CircuitClosed, breaker.State())
+}
@@ -0,0 +1,68 @@
+package chanstreamingtests_test
+
+import (
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// overflowBuffer sends 1 to 5 through the buffer before its output is read.
+func overflowBuffer[R any](buffer func(in <-chan int) <-chan R) []R {
+	source := make(chan int)
+	out := buffer(source)
+	for i := 1; i <= 5; i++ {
+		source <- i
+	}
+	close(source)
+	return ch.ToSlice(out)
+}
+
+func TestBufferedWithStrategy(t *testing.T) {
+	counters := ch.NewBufferCounters()
+	assert.Equal(t, []ch.Result[int]{ch.NewResult(4), ch.NewResult(5)},
+		overflowBuffer(ch.BufferedWithStrategy[int](2, ch.DropHead, counters)))
+	assert.Equal(t, int64(3), counters.Dropped())
+
+	counters = ch.NewBufferCounters()
+	assert.Equal(t, []ch.Result[int]{ch.NewResult(1), ch.NewResult(2)},
+		overflowBuffer(ch.BufferedWithStrategy[int](2, ch.DropTail, counters)))
+	assert.Equal(t, int64(3), counters.Dropped())
+
+}
+
+func TestBufferedWithStrategyError(t *testing.T) {
+	counters := ch.NewBufferCounters()
+	result := overflowBuffer(ch.BufferedWithStrategy[int](2, ch.Error, counters))
+	// a burst of overflows is reported by a single error
+	assert.Len(t, result, 3)
+	assert.Equal(t, []ch.Result[int]{ch.NewResult(1), ch.NewResult(2)}, result[:2])
+	assert.ErrorIs(t, result[2].Error, ch.ErrBufferOverflow)
+	assert.Equal(t, int64(3), counters.Dropped())
+}
+
+func TestBufferedWithStrategyIgnore(t *testing.T) {
+	source := make(chan int)
+	out := ch.BufferedWithStrategy[int](2, ch.Ignore, nil)(source)
+	source <- 1
+	source <- 2
+	select {
+	case source <- 3:
+		t.Fatal("the producer should be blocked on the full buffer")
+	default:
+	}
+	assert.Equal(t, ch.NewResult(1), <-out)
+	source <- 3
+	close(source)
+	assert.Equal(t, []ch.Result[int]{ch.NewResult(2), ch.NewResult(3)}, ch.ToSlice(out))
+}
+
+func TestBufferedConflate(t *testing.T) {
+	counters := ch.NewBufferCounters()
+	assert.Equal(t, []int{1, 14}, overflowBuffer(ch.BufferedConflate(2, sum, counters)))
+	assert.Equal(t, int64(3), counters.Conflated())
+	assert.Equal(t, int64(0), counters.Dropped())
+
+	keepNewest := func(pending int, x int) int { return x }
+	assert.Equal(t, []int{1, 5}, overflowBuffer(ch.BufferedConflate(2, keepNewest, nil)))
+}
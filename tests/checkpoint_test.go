@@ -0,0 +1,101 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func sum(acc int, x int) int {
+	return acc + x
+}
+
+func TestScanCheckpointedResumes(t *testing.T) {
+	store := ch.NewMemoryStateStore()
+	config := ch.CheckpointConfig{Store: store, Name: "sum"}
+
+	first := ch.ToSlice(ch.ScanCheckpointed[int, int](sum, 0, config)(ch.FromSlice([]int{1, 2, 3})))
+	assert.Equal(t, []int{1, 3, 6}, first)
+
+	// the restarted operator resumes from the snapshot taken on completion
+	second := ch.ToSlice(ch.ScanCheckpointed[int, int](sum, 0, config)(ch.FromSlice([]int{4, 5})))
+	assert.Equal(t, []int{10, 15}, second)
+}
+
+func TestScanCheckpointedEveryN(t *testing.T) {
+	store := ch.NewMemoryStateStore()
+	config := ch.CheckpointConfig{Store: store, Name: "sum"}
+	config = config.WithEveryN(2)
+	source := make(chan int)
+	states := ch.ScanCheckpointed[int, int](sum, 0, config)(source)
+
+	source <- 1
+	assert.Equal(t, 1, <-states)
+	_, ok, err := ch.LoadSnapshot[int](store, "sum")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// the checkpoint is taken before the state is emitted
+	source <- 2
+	assert.Equal(t, 3, <-states)
+	snapshot, ok, err := ch.LoadSnapshot[int](store, "sum")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, snapshot)
+
+	close(source)
+	ch.Drain(states)
+}
+
+func TestFoldByKeyCheckpointedFileStore(t *testing.T) {
+	store, err := ch.NewFileStateStore(t.TempDir())
+	assert.NoError(t, err)
+	checkpoint := ch.CheckpointConfig{Store: store, Name: "tenants/counts"}
+
+	first := ch.ToSlice(ch.FoldByKeyCheckpointed[string, string, int](firstLetter, countElements, 0, ch.KeyedStateConfig{}, checkpoint)(ch.FromSlice([]string{"a1", "b1", "a2"})))
+	assert.Equal(t, []ch.KeyedState[string, int]{{Key: "b", State: 1}, {Key: "a", State: 2}}, first)
+
+	// the final states are emitted, the snapshot taken on completion holds none of them
+	snapshot, ok, err := ch.LoadSnapshot[[]ch.KeyedState[string, int]](store, "tenants/counts")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, snapshot)
+}
+
+func TestFoldByKeyCheckpointedResumes(t *testing.T) {
+	store := ch.NewMemoryStateStore()
+	checkpoint := ch.CheckpointConfig{Store: store, Name: "counts"}
+	checkpoint = checkpoint.WithEveryN(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	source := make(chan string)
+	states := ch.FoldByKeyCheckpointedCtx[string, string, int](ctx, firstLetter, countElements, 0, ch.KeyedStateConfig{}, checkpoint)(source)
+
+	source <- "a1"
+	source <- "b1"
+	source <- "a2"
+	// the operator is stopped before the source closes, the states are resumed on restart
+	cancel()
+	ch.Drain(states)
+
+	restarted := ch.ToSlice(ch.FoldByKeyCheckpointed[string, string, int](firstLetter, countElements, 0, ch.KeyedStateConfig{}, checkpoint)(ch.FromSlice([]string{"b2", "c1"})))
+	assert.Equal(t, []ch.KeyedState[string, int]{{Key: "a", State: 2}, {Key: "b", State: 2}, {Key: "c", State: 1}}, restarted)
+
+	// a restart after completion does not emit the final states again
+	again := ch.ToSlice(ch.FoldByKeyCheckpointed[string, string, int](firstLetter, countElements, 0, ch.KeyedStateConfig{}, checkpoint)(ch.FromSlice([]string{})))
+	assert.Empty(t, again)
+}
+
+func TestCheckpointRestoreError(t *testing.T) {
+	store := ch.NewMemoryStateStore()
+	assert.NoError(t, store.Save("sum", []byte("not json")))
+	errs := []error{}
+	config := ch.CheckpointConfig{Store: store, Name: "sum"}
+	config = config.WithOnError(func(err error) { errs = append(errs, err) })
+
+	// the broken snapshot is reported and the operator starts over
+	result := ch.ToSlice(ch.FoldCheckpointed[int, int](sum, 0, config)(ch.FromSlice([]int{1, 2})))
+	assert.Equal(t, []int{3}, result)
+	assert.Len(t, errs, 1)
+}
@@ -0,0 +1,63 @@
+package chanstreamingtests_test
+
+import (
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := ch.NewManualClock(start)
+	timer := clock.NewTimer(30 * time.Millisecond)
+	stopped := clock.NewTimer(10 * time.Millisecond)
+	ticker := clock.NewTicker(20 * time.Millisecond)
+	assert.True(t, stopped.Stop())
+
+	clock.Advance(20 * time.Millisecond)
+	assert.Equal(t, start.Add(20*time.Millisecond), <-ticker.C())
+	assert.Empty(t, stopped.C())
+	assert.Empty(t, timer.C())
+
+	clock.Advance(50 * time.Millisecond)
+	assert.Equal(t, start.Add(30*time.Millisecond), <-timer.C())
+	// the ticker kept only the first of the ticks at 40 and 60 milliseconds
+	assert.Equal(t, start.Add(40*time.Millisecond), <-ticker.C())
+	assert.Empty(t, ticker.C())
+	assert.Equal(t, start.Add(70*time.Millisecond), clock.Now())
+
+	ticker.Stop()
+	assert.Equal(t, 0, clock.Waiters())
+}
+
+func TestManualClockFiresInOrder(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := ch.NewManualClock(start)
+	delays := []time.Duration{50, 10, 40, 10, 30, 20, 60}
+	timers := make([]ch.Timer, len(delays))
+	for i, delay := range delays {
+		timers[i] = clock.NewTimer(delay * time.Millisecond)
+	}
+	ticker := clock.NewTicker(25 * time.Millisecond)
+	assert.True(t, timers[2].Stop())
+
+	// each waiter is fired at its own due time, the clock passing through them in order
+	clock.Advance(55 * time.Millisecond)
+	for i, delay := range delays {
+		if i == 2 || i == 6 {
+			assert.Empty(t, timers[i].C())
+			continue
+		}
+		assert.Equal(t, start.Add(delay*time.Millisecond), <-timers[i].C())
+	}
+	assert.Equal(t, start.Add(25*time.Millisecond), <-ticker.C())
+	assert.Equal(t, 2, clock.Waiters())
+
+	clock.Advance(5 * time.Millisecond)
+	assert.Equal(t, start.Add(60*time.Millisecond), <-timers[6].C())
+	assert.False(t, timers[6].Stop())
+	ticker.Stop()
+	assert.Equal(t, 0, clock.Waiters())
+}
@@ -0,0 +1,97 @@
+package chanstreamingtests_test
+
+import (
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// secondsTimestamp reads the elements as the number of seconds since the epoch.
+func secondsTimestamp(x int) time.Time {
+	return time.Unix(int64(x), 0)
+}
+
+// collectEventTimeWindows collects the elements of the windows and the late elements.
+func collectEventTimeWindows(windows <-chan ch.Window[int], late <-chan int) ([][]int, []int) {
+	lateCollected := make(chan []int)
+	go func() {
+		lateCollected <- ch.ToSlice(late)
+	}()
+	collected := [][]int{}
+	for w := range windows {
+		collected = append(collected, w.Elements)
+	}
+	return collected, <-lateCollected
+}
+
+func TestEventTimeTumbling(t *testing.T) {
+	config := ch.EventTimeConfig{MaxOutOfOrderness: time.Second}
+	source := ch.FromSlice([]int{1, 3, 2, 6, 11, 4})
+
+	windows, late := ch.EventTimeTumbling(secondsTimestamp, 5*time.Second, config)(source)
+	collected, lateCollected := collectEventTimeWindows(windows, late)
+
+	assert.Equal(t, [][]int{{1, 3, 2}, {6}, {11}}, collected)
+	assert.Equal(t, []int{4}, lateCollected)
+}
+
+func TestEventTimeTumblingAllowedLateness(t *testing.T) {
+	config := ch.EventTimeConfig{}
+	config = config.WithAllowedLateness(5 * time.Second)
+	source := ch.FromSlice([]int{1, 6, 2, 12, 3})
+
+	windows, late := ch.EventTimeTumbling(secondsTimestamp, 5*time.Second, config)(source)
+	collected, lateCollected := collectEventTimeWindows(windows, late)
+
+	// the window of 1 is updated by 2 within the allowed lateness
+	assert.Equal(t, [][]int{{1}, {1, 2}, {6}, {12}}, collected)
+	assert.Equal(t, []int{3}, lateCollected)
+}
+
+func TestEventTimeHopping(t *testing.T) {
+	source := ch.FromSlice([]int{1, 7, 12})
+
+	windows, late := ch.EventTimeHopping(secondsTimestamp, 10*time.Second, 5*time.Second, ch.EventTimeConfig{})(source)
+	collected, lateCollected := collectEventTimeWindows(windows, late)
+
+	assert.Equal(t, [][]int{{1}, {1, 7}, {7, 12}, {12}}, collected)
+	assert.Empty(t, lateCollected)
+}
+
+func TestEventTimeSliding(t *testing.T) {
+	source := ch.FromSlice([]int{1, 2, 3, 6})
+
+	windows, late := ch.EventTimeSliding(secondsTimestamp, 2*time.Second, ch.EventTimeConfig{})(source)
+	collected, lateCollected := collectEventTimeWindows(windows, late)
+
+	assert.Equal(t, [][]int{{1}, {1, 2}, {1, 2, 3}, {6}}, collected)
+	assert.Empty(t, lateCollected)
+}
+
+func TestEventTimeSession(t *testing.T) {
+	config := ch.EventTimeConfig{MaxOutOfOrderness: 2 * time.Second}
+	source := ch.FromSlice([]int{1, 3, 2, 10, 11, 20})
+
+	windows, late := ch.EventTimeSession(secondsTimestamp, 3*time.Second, config)(source)
+	collected, lateCollected := collectEventTimeWindows(windows, late)
+
+	assert.Equal(t, [][]int{{1, 2, 3}, {10, 11}, {20}}, collected)
+	assert.Empty(t, lateCollected)
+}
+
+func TestEventTimeHoppingGaps(t *testing.T) {
+	// with `slide` exceeding `size` the elements between the windows are dropped, not late
+	windows, late := ch.EventTimeHopping(secondsTimestamp, 2*time.Second, 5*time.Second, ch.EventTimeConfig{})(ch.FromSlice([]int{1, 3, 6, 8}))
+	collected, lateElements := collectEventTimeWindows(windows, late)
+	assert.Equal(t, [][]int{{1}, {6}}, collected)
+	assert.Empty(t, lateElements)
+}
+
+func TestEventTimeWindowsRejectNonPositiveSizes(t *testing.T) {
+	assert.Panics(t, func() { ch.EventTimeTumbling(secondsTimestamp, 0, ch.EventTimeConfig{}) })
+	assert.Panics(t, func() { ch.EventTimeHopping(secondsTimestamp, time.Second, 0, ch.EventTimeConfig{}) })
+	assert.Panics(t, func() { ch.EventTimeSliding(secondsTimestamp, -time.Second, ch.EventTimeConfig{}) })
+	assert.Panics(t, func() { ch.EventTimeSession(secondsTimestamp, 0, ch.EventTimeConfig{}) })
+}
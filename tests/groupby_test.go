@@ -0,0 +1,90 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// joinGroup folds each group into a string of its key and elements.
+func joinGroup(key string, elements <-chan string) <-chan string {
+	return ch.Fold(func(acc string, x string) string {
+		return acc + " " + x
+	}, key+":")(elements)
+}
+
+func TestGroupBy(t *testing.T) {
+	source := ch.FromSlice([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	groups := ch.GroupBy[int, int](func(x int) int { return x % 3 }, ch.GroupByConfig{})(source)
+	sums := ch.MergeGroups(func(key int, elements <-chan int) <-chan string {
+		return ch.Mapped(func(sum int) string {
+			return fmt.Sprintf("%d:%d", key, sum)
+		})(ch.Fold(func(acc int, x int) int { return acc + x }, 0)(elements))
+	})(groups)
+
+	result := ch.ToSlice(sums)
+	sort.Strings(result)
+	assert.Equal(t, []string{"0:18", "1:22", "2:15"}, result)
+}
+
+func TestGroupByMaxGroups(t *testing.T) {
+	config := ch.GroupByConfig{}
+	config = config.WithMaxGroups(2)
+
+	// the elements of the keys beyond the limit are dropped
+	dropTail := config.WithOverflowStrategy(ch.DropTail)
+	source := ch.FromSlice([]string{"a1", "b1", "c1", "a2", "b2"})
+	result := ch.ToSlice(ch.MergeGroups(joinGroup)(ch.GroupBy[string, string](firstLetter, dropTail)(source)))
+	sort.Strings(result)
+	assert.Equal(t, []string{"a: a1 a2", "b: b1 b2"}, result)
+
+	// the least recently active group is closed to make room for the new one
+	dropHead := config.WithOverflowStrategy(ch.DropHead)
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	input := make(chan string)
+	merged := ch.MergeGroups(joinGroup)(ch.GroupByCtx[string, string](ctx, firstLetter, dropHead)(input))
+	collected := make(chan []string, 1)
+	go func() {
+		collected <- ch.ToSlice(merged)
+	}()
+	input <- "a1"
+	input <- "b1"
+	clock.Advance(time.Second)
+	input <- "b2"
+	input <- "c1"
+	close(input)
+
+	result = <-collected
+	sort.Strings(result)
+	assert.Equal(t, []string{"a: a1", "b: b1 b2", "c: c1"}, result)
+}
+
+func TestGroupByIdleTimeout(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	config := ch.GroupByConfig{}
+	config = config.WithIdleTimeout(10 * time.Second)
+	source := make(chan string)
+	merged := ch.MergeGroups(joinGroup)(ch.GroupByCtx[string, string](ctx, firstLetter, config)(source))
+	collected := make(chan []string, 1)
+	go func() {
+		collected <- ch.ToSlice(merged)
+	}()
+
+	// the "z" element makes sure "a1" is stamped before the clock moves
+	source <- "a1"
+	source <- "z"
+	clock.Advance(10 * time.Second)
+	source <- "a2"
+	close(source)
+
+	result := <-collected
+	sort.Strings(result)
+	assert.Equal(t, []string{"a: a1", "a: a2", "z: z"}, result)
+}
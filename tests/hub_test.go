@@ -0,0 +1,67 @@
+package chanstreamingtests_test
+
+import (
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcast(t *testing.T) {
+	outputs := ch.Broadcast[int](3)(ch.FromSlice([]int{1, 2, 3}))
+	collected := make(chan []int, len(outputs))
+	for _, output := range outputs {
+		go func(output <-chan int) {
+			collected <- ch.ToSlice(output)
+		}(output)
+	}
+	for range outputs {
+		assert.Equal(t, []int{1, 2, 3}, <-collected)
+	}
+}
+
+// hubSlowSubscriber feeds the Hub with 1 to 4 while a blocking subscriber keeps up,
+// returning what the slow subscriber reads once the source is done.
+func hubSlowSubscriber(bufferSize int, strategy ch.OverflowStrategy) []int {
+	source := make(chan int)
+	hub := ch.NewHub(source)
+	slow := hub.Subscribe(bufferSize, strategy)
+	fast := hub.Subscribe(1, ch.Ignore)
+	fastCollected := make(chan []int, 1)
+	go func() {
+		fastCollected <- ch.ToSlice(fast)
+	}()
+	for i := 1; i <= 4; i++ {
+		source <- i
+	}
+	close(source)
+	<-fastCollected
+	return ch.ToSlice(slow)
+}
+
+func TestHubSlowSubscriberPolicies(t *testing.T) {
+	assert.Equal(t, []int{3, 4}, hubSlowSubscriber(2, ch.DropHead))
+	assert.Equal(t, []int{1, 2}, hubSlowSubscriber(2, ch.DropTail))
+	// the subscriber is disconnected on the first overflow
+	assert.Equal(t, []int{1, 2}, hubSlowSubscriber(2, ch.Error))
+}
+
+func TestHubBlockingSubscriber(t *testing.T) {
+	source := make(chan int)
+	hub := ch.NewHub(source)
+	subscription := hub.Subscribe(1, ch.Ignore)
+	all := hub.Subscribe(4, ch.Ignore)
+	assert.Equal(t, 2, hub.Subscribers())
+
+	source <- 1
+	source <- 2
+	// unsubscribing releases the Hub blocked on the full buffer
+	hub.Unsubscribe(subscription)
+	source <- 3
+	close(source)
+
+	assert.Equal(t, []int{1}, ch.ToSlice(subscription))
+	assert.Equal(t, []int{1, 2, 3}, ch.ToSlice(all))
+	assert.Equal(t, 0, hub.Subscribers())
+	assert.Empty(t, ch.ToSlice(hub.Subscribe(1, ch.Ignore)))
+}
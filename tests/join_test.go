@@ -0,0 +1,102 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	id     int
+	amount int
+}
+
+type payment struct {
+	orderId int
+	paid    int
+}
+
+func describeJoined(key int, o *order, p *payment) string {
+	result := fmt.Sprintf("%d:", key)
+	if o != nil {
+		result += fmt.Sprintf(" order %d", o.amount)
+	}
+	if p != nil {
+		result += fmt.Sprintf(" paid %d", p.paid)
+	}
+	return result
+}
+
+func joinOrdersPayments(ctx context.Context, mode ch.JoinMode, orders <-chan order, payments <-chan payment) []string {
+	config := ch.JoinConfig{}
+	config = config.WithMode(mode)
+	joined := ch.JoinWithinCtx[int, order, payment, string](ctx,
+		func(o order) int { return o.id },
+		func(p payment) int { return p.orderId },
+		time.Minute, describeJoined, config)(orders, payments)
+	result := ch.ToSlice(joined)
+	sort.Strings(result)
+	return result
+}
+
+func TestJoinWithin(t *testing.T) {
+	// the clock never moves, so every element stays in the window until the sources close
+	ctx := ch.ContextWithClock(context.Background(), ch.NewManualClock(time.Unix(0, 0)))
+	orders := []order{{id: 1, amount: 10}, {id: 2, amount: 20}, {id: 3, amount: 30}}
+	payments := []payment{{orderId: 2, paid: 20}, {orderId: 3, paid: 15}, {orderId: 3, paid: 15}, {orderId: 4, paid: 40}}
+
+	inner := joinOrdersPayments(ctx, ch.InnerJoin, ch.FromSlice(orders), ch.FromSlice(payments))
+	assert.Equal(t, []string{"2: order 20 paid 20", "3: order 30 paid 15", "3: order 30 paid 15"}, inner)
+
+	left := joinOrdersPayments(ctx, ch.LeftJoin, ch.FromSlice(orders), ch.FromSlice(payments))
+	assert.Equal(t, []string{"1: order 10", "2: order 20 paid 20", "3: order 30 paid 15", "3: order 30 paid 15"}, left)
+
+	outer := joinOrdersPayments(ctx, ch.FullOuterJoin, ch.FromSlice(orders), ch.FromSlice(payments))
+	assert.Equal(t, []string{"1: order 10", "2: order 20 paid 20", "3: order 30 paid 15", "3: order 30 paid 15", "4: paid 40"}, outer)
+}
+
+func TestJoinWithinExpiry(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	orders := make(chan order)
+	payments := make(chan payment)
+	collected := make(chan []string, 1)
+	go func() {
+		collected <- joinOrdersPayments(ctx, ch.LeftJoin, orders, payments)
+	}()
+
+	// the second order makes sure the first one is stamped before the clock moves
+	orders <- order{id: 1, amount: 10}
+	orders <- order{id: 9, amount: 90}
+	clock.Advance(time.Minute)
+	payments <- payment{orderId: 1, paid: 10}
+	close(orders)
+	close(payments)
+
+	// the order expired unmatched before its payment arrived
+	assert.Equal(t, []string{"1: order 10", "9: order 90"}, <-collected)
+}
+
+func TestJoinWithinMaxSize(t *testing.T) {
+	ctx := ch.ContextWithClock(context.Background(), ch.NewManualClock(time.Unix(0, 0)))
+	config := ch.JoinConfig{}
+	config = config.WithMode(ch.LeftJoin)
+	config = config.WithMaxSize(1)
+	orders := make(chan order)
+	joined := ch.JoinWithinCtx[int, order, payment, string](ctx,
+		func(o order) int { return o.id },
+		func(p payment) int { return p.orderId },
+		time.Minute, describeJoined, config)(orders, ch.FromSlice([]payment{}))
+
+	// the buffered order is expired early to make room for the next one
+	orders <- order{id: 1, amount: 10}
+	orders <- order{id: 2, amount: 20}
+	assert.Equal(t, "1: order 10", <-joined)
+	close(orders)
+	assert.Equal(t, []string{"2: order 20"}, ch.ToSlice(joined))
+}
@@ -0,0 +1,68 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func countElements(count int, _ string) int {
+	return count + 1
+}
+
+func TestScanByKey(t *testing.T) {
+	source := ch.FromSlice([]string{"a1", "b1", "a2"})
+	result := ch.ToSlice(ch.ScanByKey[string, string, int](firstLetter, countElements, 0, ch.KeyedStateConfig{})(source))
+	assert.Equal(t, []ch.KeyedState[string, int]{{Key: "a", State: 1}, {Key: "b", State: 1}, {Key: "a", State: 2}}, result)
+
+	// the final states follow the running ones, in the order of their last update
+	config := ch.KeyedStateConfig{}
+	config = config.WithEmitFinal(true)
+	source = ch.FromSlice([]string{"a1", "b1", "a2"})
+	result = ch.ToSlice(ch.ScanByKey[string, string, int](firstLetter, countElements, 0, config)(source))
+	assert.Equal(t, []ch.KeyedState[string, int]{{Key: "a", State: 1}, {Key: "b", State: 1}, {Key: "a", State: 2}, {Key: "b", State: 1}, {Key: "a", State: 2}}, result)
+}
+
+func TestFoldByKey(t *testing.T) {
+	source := ch.FromSlice([]int{1, 2, 3, 4, 5, 6})
+	result := ch.ToSlice(ch.FoldByKey[int, int, int](func(x int) int { return x % 2 }, func(sum int, x int) int {
+		return sum + x
+	}, 0, ch.KeyedStateConfig{})(source))
+	assert.Equal(t, []ch.KeyedState[int, int]{{Key: 1, State: 9}, {Key: 0, State: 12}}, result)
+}
+
+func TestReduceByKeyMaxKeys(t *testing.T) {
+	config := ch.KeyedStateConfig{}
+	config = config.WithMaxKeys(1)
+	source := ch.FromSlice([]string{"a1", "a2", "b1", "a3"})
+	result := ch.ToSlice(ch.ReduceByKey[string, string](firstLetter, func(acc string, x string) string {
+		return acc + x
+	}, config)(source))
+	assert.Equal(t, []ch.KeyedState[string, string]{{Key: "a", State: "a1a2"}, {Key: "b", State: "b1"}, {Key: "a", State: "a3"}}, result)
+}
+
+func TestFoldByKeyTTL(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	config := ch.KeyedStateConfig{}
+	config = config.WithTTL(10 * time.Second)
+	source := make(chan string)
+	states := ch.FoldByKeyCtx[string, string, int](ctx, firstLetter, countElements, 0, config)(source)
+	collected := make(chan []ch.KeyedState[string, int], 1)
+	go func() {
+		collected <- ch.ToSlice(ch.Filter(func(s ch.KeyedState[string, int]) bool { return s.Key != "z" })(states))
+	}()
+
+	// the "z" element makes sure "a1" is stamped before the clock moves
+	source <- "a1"
+	source <- "z"
+	clock.Advance(10 * time.Second)
+	source <- "a2"
+	close(source)
+
+	// the expired state is emitted and the key starts over
+	assert.Equal(t, []ch.KeyedState[string, int]{{Key: "a", State: 1}, {Key: "a", State: 1}}, <-collected)
+}
@@ -0,0 +1,418 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertNoLeakedGoroutines fails the test if the number of goroutines does not return to `before` in time.
+func assertNoLeakedGoroutines(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "leaked goroutines")
+}
+
+// endless produces a source that never closes on its own, stopping only on `stop`.
+func endless(stop <-chan struct{}) <-chan int {
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			case out <- i:
+			}
+		}
+	}()
+	return out
+}
+
+// toInts maps the elements of any operator output to ints, so that the operators fit a LeakTestCase.
+func toInts[T any](ctx context.Context, in <-chan T) <-chan int {
+	return ch.MappedCtx(ctx, func(T) int { return 0 })(in)
+}
+
+// asResults works like AsResults, closing on cancellation of the context.Context.
+func asResults(ctx context.Context, in <-chan int) <-chan ch.Result[int] {
+	return ch.MappedCtx(ctx, ch.NewResult[int])(in)
+}
+
+// twoSides splits the source for the operators taking several sources.
+func twoSides(ctx context.Context, in <-chan int) (<-chan int, <-chan int) {
+	sides := ch.BroadcastCtx[int](ctx, 2)(in)
+	return sides[0], sides[1]
+}
+
+// eventTime makes each element of an endless source its own second of event time.
+func eventTime(i int) time.Time {
+	return time.Unix(int64(i), 0)
+}
+
+func identityCall(i int) (int, error) {
+	return i, nil
+}
+
+// LeakTestCase describes an operator applied to a source whose consumer walks away after a couple of elements.
+type LeakTestCase struct {
+	Name     string
+	Operator func(ctx context.Context, in <-chan int) <-chan int
+}
+
+func TestNoLeakedGoroutines(t *testing.T) {
+	leakTests := []LeakTestCase{
+		{"MappedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.MappedCtx(ctx, func(i int) int { return i })(in)
+		}},
+		{"FilterCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.FilterCtx(ctx, func(i int) bool { return true })(in)
+		}},
+		{"MapCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.MapCtx(ctx, func(i int) int { return i }, 4)(in)
+		}},
+		{"MapUnorderedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.MapUnorderedCtx(ctx, func(i int) int { return i }, 4)(in)
+		}},
+		{"FlatMapSliceCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.FlatMapSliceCtx(ctx, func(i int) []int { return []int{i, i} })(in)
+		}},
+		{"ScanCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.ScanCtx(ctx, func(acc, i int) int { return acc + i }, 0)(in)
+		}},
+		{"BatchCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			batched := ch.BatchCtx[int](ctx, 2, time.Millisecond*10)(in)
+			return ch.FlatMapSliceCtx(ctx, ch.Identity[[]int])(batched)
+		}},
+		{"ThrottleCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.ThrottleCtx[int](ctx, time.Millisecond)(in)
+		}},
+		{"BufferedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.BufferedCtx[int](ctx, 4)(in)
+		}},
+		{"PartitionCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.MergeCtx(ctx, ch.PartitionCtx(ctx, 3, ch.Identity[int])(in))
+		}},
+		{"BroadcastCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.BroadcastCtx[int](ctx, 3)(in)[0]
+		}},
+		{"MergeCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.MergeCtx(ctx, []<-chan int{in, ch.FromSliceCtx(ctx, []int{1, 2})})
+		}},
+		{"ApplyCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.ApplyCtx(ctx, func(int) {})(in)
+		}},
+		{"WhenDoneCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.WhenDoneCtx[int](ctx, func() {})(in)
+		}},
+		{"FromSliceCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.FromSliceCtx(ctx, make([]int, 100))
+		}},
+		{"UnfoldSafeCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.UnfoldSafeCtx(ctx, func(s int) (int, int, bool) { return s + 1, s, true }, 0))
+		}},
+		{"ToSliceCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			out := make(chan int)
+			go func() {
+				defer close(out)
+				_, _ = ch.ToSliceCtx(ctx, in)
+			}()
+			return out
+		}},
+		{"CollectWhileCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			_, tail := ch.CollectWhileCtx(ctx, func(i int) bool { return i < 2 })(in)
+			return tail
+		}},
+		{"FlatMapCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.FlatMapCtx(ctx, func(i int) <-chan int { return ch.FromSliceCtx(ctx, []int{i, i}) })(in)
+		}},
+		{"ConcatCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.ConcatCtx(ctx, ch.FromSliceCtx(ctx, []int{1, 2}), in)
+		}},
+		{"MergePrioritizedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return ch.MergePrioritizedCtx(ctx, []<-chan int{left, right})
+		}},
+		{"MergeWeightedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return ch.MergeWeightedCtx(ctx, []<-chan int{left, right}, []int{1, 2})
+		}},
+		{"MergeSortedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return ch.MergeSortedCtx(ctx, lessInt, left, right)
+		}},
+		{"MergeSortedTimeoutCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return ch.MergeSortedTimeoutCtx(ctx, lessInt, time.Millisecond*10, left, right)
+		}},
+		{"ZipCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return toInts(ctx, ch.ZipCtx[int, int](ctx, ch.ZipLongest)(left, right))
+		}},
+		{"ZipWithCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return ch.ZipWithCtx(ctx, func(a, b int) int { return a + b }, ch.ZipShortest)(left, right)
+		}},
+		{"ZipLatestCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return toInts(ctx, ch.ZipLatestCtx[int, int](ctx)(left, right))
+		}},
+		{"CombineLatestCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return toInts(ctx, ch.CombineLatestCtx(ctx, left, right))
+		}},
+		{"WithLatestFromCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			return toInts(ctx, ch.WithLatestFromCtx[int](ctx, right)(left))
+		}},
+		{"JoinWithinCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			left, right := twoSides(ctx, in)
+			join := ch.JoinWithinCtx(ctx, ch.Identity[int], ch.Identity[int], time.Millisecond*10,
+				func(key int, left *int, right *int) int { return key }, ch.JoinConfig{Mode: ch.FullOuterJoin, MaxSize: 4})
+			return join(left, right)
+		}},
+		{"NewHubCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.NewHubCtx(ctx, in).Subscribe(4, ch.DropHead)
+		}},
+		{"NewMergeHubCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			hub := ch.NewMergeHubCtx[int](ctx)
+			_ = hub.Attach(in)
+			return hub.Out()
+		}},
+		{"GroupByCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			groups := ch.GroupByCtx(ctx, func(i int) int { return i % 3 }, ch.GroupByConfig{MaxGroups: 2, OverflowStrategy: ch.DropHead})(in)
+			return ch.FlatMapCtx(ctx, func(group ch.Group[int, int]) <-chan int { return group.Elements })(groups)
+		}},
+		{"MergeGroupsCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			groups := ch.GroupByCtx(ctx, func(i int) int { return i % 3 }, ch.GroupByConfig{})(in)
+			return ch.MergeGroupsCtx(ctx, func(key int, elements <-chan int) <-chan int { return elements })(groups)
+		}},
+		{"BatchWeightedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			batched := ch.BatchWeightedCtx(ctx, func(int) int { return 1 }, 4, 4, time.Millisecond*10)(in)
+			return ch.FlatMapSliceCtx(ctx, ch.Identity[[]int])(batched)
+		}},
+		{"WithSlidingWindowCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.WithSlidingWindowCtx[int](ctx, ch.WindowConfig{MaxSize: 4})(in))
+		}},
+		{"TumblingWindowCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.TumblingWindowCtx[int](ctx, time.Millisecond*10)(in))
+		}},
+		{"HoppingWindowCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.HoppingWindowCtx[int](ctx, time.Millisecond*10, time.Millisecond*5)(in))
+		}},
+		{"CountWindowCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.CountWindowCtx[int](ctx, 4, 2)(in))
+		}},
+		{"TumblingWindowAggregateCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.TumblingWindowAggregateCtx(ctx, time.Millisecond*10, ch.NewSumAggregator[int])(in))
+		}},
+		{"HoppingWindowAggregateCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.HoppingWindowAggregateCtx(ctx, time.Millisecond*10, time.Millisecond*5, ch.NewSumAggregator[int])(in))
+		}},
+		{"CountWindowAggregateCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.CountWindowAggregateCtx(ctx, 4, 2, ch.NewSumAggregator[int])(in))
+		}},
+		{"SessionWindowCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			sessions := ch.SessionWindowCtx(ctx, func(i int) int { return i % 3 }, time.Millisecond, ch.SessionConfig{MaxLength: time.Millisecond * 5})
+			return toInts(ctx, sessions(in))
+		}},
+		{"EventTimeTumblingCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			windows, late := ch.EventTimeTumblingCtx(ctx, eventTime, time.Second*2, ch.EventTimeConfig{})(in)
+			return ch.MergeCtx(ctx, []<-chan int{toInts(ctx, windows), late})
+		}},
+		{"EventTimeHoppingCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			windows, late := ch.EventTimeHoppingCtx(ctx, eventTime, time.Second*2, time.Second, ch.EventTimeConfig{})(in)
+			return ch.MergeCtx(ctx, []<-chan int{toInts(ctx, windows), late})
+		}},
+		{"EventTimeSlidingCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			windows, late := ch.EventTimeSlidingCtx(ctx, eventTime, time.Second*2, ch.EventTimeConfig{})(in)
+			return ch.MergeCtx(ctx, []<-chan int{toInts(ctx, windows), late})
+		}},
+		{"EventTimeSessionCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			windows, late := ch.EventTimeSessionCtx(ctx, eventTime, time.Second*2, ch.EventTimeConfig{})(in)
+			return ch.MergeCtx(ctx, []<-chan int{toInts(ctx, windows), late})
+		}},
+		{"FoldCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.FoldCtx(ctx, func(acc, i int) int { return acc + i }, 0)(in)
+		}},
+		{"FoldCheckpointedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			config := ch.CheckpointConfig{Store: ch.NewMemoryStateStore(), Name: "fold", EveryN: 2}
+			return ch.FoldCheckpointedCtx(ctx, func(acc, i int) int { return acc + i }, 0, config)(in)
+		}},
+		{"ScanCheckpointedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			config := ch.CheckpointConfig{Store: ch.NewMemoryStateStore(), Name: "scan", Interval: time.Millisecond}
+			return ch.ScanCheckpointedCtx(ctx, func(acc, i int) int { return acc + i }, 0, config)(in)
+		}},
+		{"ScanByKeyCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			scan := ch.ScanByKeyCtx(ctx, func(i int) int { return i % 3 }, func(acc, i int) int { return acc + i }, 0, ch.KeyedStateConfig{TTL: time.Millisecond})
+			return toInts(ctx, scan(in))
+		}},
+		{"ScanByKeyCheckpointedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			config := ch.CheckpointConfig{Store: ch.NewMemoryStateStore(), Name: "scan by key", EveryN: 2}
+			scan := ch.ScanByKeyCheckpointedCtx(ctx, func(i int) int { return i % 3 }, func(acc, i int) int { return acc + i }, 0, ch.KeyedStateConfig{MaxKeys: 2}, config)
+			return toInts(ctx, scan(in))
+		}},
+		{"FoldByKeyCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			fold := ch.FoldByKeyCtx(ctx, func(i int) int { return i % 3 }, func(acc, i int) int { return acc + i }, 0, ch.KeyedStateConfig{})
+			return toInts(ctx, fold(in))
+		}},
+		{"FoldByKeyCheckpointedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			config := ch.CheckpointConfig{Store: ch.NewMemoryStateStore(), Name: "fold by key", EveryN: 2}
+			fold := ch.FoldByKeyCheckpointedCtx(ctx, func(i int) int { return i % 3 }, func(acc, i int) int { return acc + i }, 0, ch.KeyedStateConfig{}, config)
+			return toInts(ctx, fold(in))
+		}},
+		{"ReduceByKeyCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			reduce := ch.ReduceByKeyCtx(ctx, func(i int) int { return i % 3 }, func(a, b int) int { return a + b }, ch.KeyedStateConfig{EmitFinal: true})
+			return toInts(ctx, reduce(in))
+		}},
+		{"JitterCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.JitterCtx[int](ctx, time.Millisecond)(in)
+		}},
+		{"RateLimitCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.RateLimitCtx[int](ctx, ch.NewTokenBucket(1, time.Millisecond, 2))(in)
+		}},
+		{"RateLimitWeightedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.RateLimitWeightedCtx(ctx, ch.NewSlidingWindowLog(4, time.Millisecond), func(int) int { return 2 })(in)
+		}},
+		{"BufferedWithStrategyCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.BufferedWithStrategyCtx[int](ctx, 4, ch.Error, nil)(in))
+		}},
+		{"BufferedConflateCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.BufferedConflateCtx(ctx, 4, func(pending, i int) int { return pending + i }, nil)(in)
+		}},
+		{"SpillBufferCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.SpillBufferCtx[int](ctx, ch.JSONCodec[int]{}, ch.SpillConfig{MemorySize: 4})(in))
+		}},
+		{"CatchCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.CatchCtx[int](ctx, func(error) {})(ch.MapSafeCtx(ctx, ch.Identity[int], 4)(in))
+		}},
+		{"MapSafeCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.MapSafeCtx(ctx, ch.Identity[int], 4)(in))
+		}},
+		{"MapUnorderedSafeCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.MapUnorderedSafeCtx(ctx, ch.Identity[int], 4)(in))
+		}},
+		{"MapSafeAsyncCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.MapSafeAsyncCtx(ctx, ch.Identity[int], 4)(in))
+		}},
+		{"MapUnorderedSafeAsyncCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.MapUnorderedSafeAsyncCtx(ctx, ch.Identity[int], 4)(in))
+		}},
+		{"MapSafeTimeoutCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			fn := func(ctx context.Context, i int) int { return i }
+			return toInts(ctx, ch.MapSafeTimeoutCtx(ctx, fn, time.Second, 4, nil)(in))
+		}},
+		{"NewAsyncResultCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.FlatMapCtx(ctx, func(i int) <-chan int {
+				return toInts(ctx, ch.NewAsyncResultCtx(ctx, func(context.Context) int { return i }))
+			})(in)
+		}},
+		{"TryMapCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.TryMapCtx(ctx, identityCall, ch.CollectErrors)(asResults(ctx, in)))
+		}},
+		{"TryFilterCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			predicate := func(int) (bool, error) { return true, nil }
+			return toInts(ctx, ch.TryFilterCtx(ctx, predicate, ch.ShortCircuit)(asResults(ctx, in)))
+		}},
+		{"TryFlatMapCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			fn := func(i int) <-chan ch.Result[int] { return asResults(ctx, ch.FromSliceCtx(ctx, []int{i, i})) }
+			return toInts(ctx, ch.TryFlatMapCtx(ctx, fn, ch.SkipErrors)(asResults(ctx, in)))
+		}},
+		{"TryFoldCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			fn := func(acc, i int) (int, error) { return acc + i, nil }
+			return toInts(ctx, ch.TryFoldCtx(ctx, fn, 0, ch.CollectErrors)(asResults(ctx, in)))
+		}},
+		{"RetryCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.MappedCtx(ctx, func(i int) int {
+				return ch.RetryCtx(ctx, func() (int, error) { return identityCall(i) }, ch.RetryPolicy{MaxAttempts: 2}).Data
+			})(in)
+		}},
+		{"NewAsyncRetryCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return ch.FlatMapCtx(ctx, func(i int) <-chan int {
+				retried := ch.NewAsyncRetryCtx(ctx, func() (int, error) { return identityCall(i) }, ch.RetryPolicy{MaxAttempts: 2})
+				return toInts(ctx, retried)
+			})(in)
+		}},
+		{"MapRetryCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.MapRetryCtx(ctx, identityCall, ch.RetryPolicy{MaxAttempts: 2}, 4)(in))
+		}},
+		{"MapUnorderedRetryCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.MapUnorderedRetryCtx(ctx, identityCall, ch.RetryPolicy{MaxAttempts: 2}, 4)(in))
+		}},
+		{"MapCircuitBreakerCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			breaker := ch.NewCircuitBreaker(ch.CircuitBreakerConfig{ConsecutiveFailures: 3, CoolDown: time.Second})
+			return toInts(ctx, ch.MapCircuitBreakerCtx(ctx, breaker, identityCall, 4)(in))
+		}},
+		{"MapAdaptiveCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			limiter := ch.NewAdaptiveLimiter(ch.AdaptiveLimiterConfig{InitialLimit: 2, MaxLimit: 4})
+			return toInts(ctx, ch.MapAdaptiveCtx(ctx, identityCall, limiter)(in))
+		}},
+		{"MapUnorderedAdaptiveCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			limiter := ch.NewAdaptiveLimiter(ch.AdaptiveLimiterConfig{Algorithm: ch.Gradient, InitialLimit: 2, MaxLimit: 4})
+			return toInts(ctx, ch.MapUnorderedAdaptiveCtx(ctx, identityCall, limiter)(in))
+		}},
+		{"MapOrderedBoundedCtx", func(ctx context.Context, in <-chan int) <-chan int {
+			return toInts(ctx, ch.MapOrderedBoundedCtx(ctx, identityCall, 4, 4, time.Second)(in))
+		}},
+	}
+
+	for _, leakTest := range leakTests {
+		t.Run(leakTest.Name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+			ctx, cancel := context.WithCancel(context.Background())
+			stop := make(chan struct{})
+
+			out := leakTest.Operator(ctx, endless(stop))
+			// the operators emitting on completion only give nothing before the walk away
+			for range 2 {
+				select {
+				case <-out:
+				case <-time.After(time.Millisecond * 50):
+				}
+			}
+			// walk away without draining the output
+			cancel()
+			close(stop)
+
+			assertNoLeakedGoroutines(t, before)
+		})
+	}
+}
+
+func TestDetach(t *testing.T) {
+	before := runtime.NumGoroutine()
+	source := ch.Mapped(func(i int) int { return i })(ch.FromSlice(make([]int, 100)))
+
+	detached, detach := ch.Detach(source)
+	<-detached
+	detach()
+	detach()
+
+	// the detached output closes and the upstream operators finish without a reader
+	ch.Drain(detached)
+	assertNoLeakedGoroutines(t, before)
+}
+
+func TestDrainCollectWhileTail(t *testing.T) {
+	before := runtime.NumGoroutine()
+	collected, tail := ch.CollectWhile(func(i int) bool { return i < 3 })(ch.FromSlice([]int{1, 2, 3, 4, 5}))
+
+	<-ch.DrainAsync(tail)
+	assert.Equal(t, []int{1, 2}, collected)
+	assertNoLeakedGoroutines(t, before)
+}
+
+func TestToContextDrainsSource(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx := ch.ToContext(ch.Mapped(func(i int) int { return i })(ch.FromSlice([]int{1, 2, 3, 4, 5})))
+
+	<-ctx.Done()
+	assertNoLeakedGoroutines(t, before)
+}
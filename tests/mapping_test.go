@@ -1,6 +1,7 @@
 package chanstreamingtests_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -64,3 +65,39 @@ func TestMapUnordered(t *testing.T) {
 	expected := []int{18, 16, 14, 12, 10, 8, 6, 4, 2, 0}
 	assert.Equal(t, expected, result)
 }
+
+func TestMapCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// endless source that only stops on cancellation
+	source := ch.UnfoldSafeCtx(ctx, func(state int) (int, int, bool) {
+		return state + 1, state, true
+	}, 0)
+
+	out := ch.MapCtx(ctx, func(i int) int {
+		return i * 2
+	}, 5)(ch.Muted(source))
+
+	assert.Equal(t, 0, <-out)
+	assert.Equal(t, 2, <-out)
+	cancel()
+
+	// the output must close even though nobody drains the source
+	for range out {
+	}
+}
+
+func TestMapUnorderedCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	source := make(chan int)
+	out := ch.MapUnorderedCtx(ctx, func(i int) int {
+		return i
+	}, 3)(source)
+
+	source <- 1
+	assert.Equal(t, 1, <-out)
+	cancel()
+
+	// the workers must stop without the source being closed
+	for range out {
+	}
+}
@@ -0,0 +1,87 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeHub(t *testing.T) {
+	hub := ch.NewMergeHub[int]()
+	collected := make(chan []int, 1)
+	go func() {
+		collected <- ch.ToSlice(hub.Out())
+	}()
+
+	assert.NoError(t, hub.Attach(ch.FromSlice([]int{1, 2, 3})))
+	late := make(chan int)
+	assert.NoError(t, hub.Attach(late))
+	hub.Seal()
+	assert.ErrorIs(t, hub.Attach(ch.FromSlice([]int{100})), ch.ErrMergeHubSealed)
+
+	// the output stays open until the sources attached before sealing are done
+	late <- 4
+	late <- 5
+	close(late)
+
+	result := <-collected
+	sort.Ints(result)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, result)
+}
+
+func TestMergeHubDetach(t *testing.T) {
+	hub := ch.NewMergeHub[int]()
+	source := make(chan int)
+	assert.NoError(t, hub.Attach(source))
+
+	source <- 1
+	assert.Equal(t, 1, <-hub.Out())
+	<-hub.Detach(source)
+
+	// the rest of the source is left to the caller
+	go func() {
+		source <- 2
+		close(source)
+	}()
+	assert.Equal(t, []int{2}, ch.ToSlice(source))
+
+	hub.Seal()
+	assert.Empty(t, ch.ToSlice(hub.Out()))
+}
+
+func TestMergeHubCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := ch.NewMergeHubCtx[int](ctx)
+	assert.NoError(t, hub.Attach(make(chan int)))
+
+	// cancellation closes the output without sealing or completing the sources
+	cancel()
+	assert.Empty(t, ch.ToSlice(hub.Out()))
+	assert.ErrorIs(t, hub.Attach(make(chan int)), ch.ErrMergeHubSealed)
+}
+
+func TestMergeHubReattach(t *testing.T) {
+	hub := ch.NewMergeHub[int]()
+	source := make(chan int)
+	assert.NoError(t, hub.Attach(source))
+
+	source <- 1
+	assert.Equal(t, 1, <-hub.Out())
+	// attaching right after detaching reads the source again
+	stopped := hub.Detach(source)
+	assert.NoError(t, hub.Attach(source))
+	<-stopped
+
+	source <- 2
+	assert.Equal(t, 2, <-hub.Out())
+	<-hub.Detach(source)
+	assert.NoError(t, hub.Attach(source))
+	hub.Seal()
+
+	source <- 3
+	close(source)
+	assert.Equal(t, []int{3}, ch.ToSlice(hub.Out()))
+}
@@ -0,0 +1,51 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func lessInt(a int, b int) bool {
+	return a < b
+}
+
+func TestMergeSorted(t *testing.T) {
+	merged := ch.MergeSorted(lessInt,
+		ch.FromSlice([]int{1, 4, 7, 10}),
+		ch.FromSlice([]int{2, 5, 8}),
+		ch.FromSlice([]int{}),
+		ch.FromSlice([]int{3, 6, 9, 11, 12}),
+	)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, ch.ToSlice(merged))
+}
+
+func TestMergeSortedTies(t *testing.T) {
+	// equal elements are emitted in the order of their sources
+	byLength := func(a string, b string) bool { return len(a) < len(b) }
+	merged := ch.MergeSorted(byLength, ch.FromSlice([]string{"b", "bb"}), ch.FromSlice([]string{"a", "aa"}))
+	assert.Equal(t, []string{"b", "a", "bb", "aa"}, ch.ToSlice(merged))
+}
+
+func TestMergeSortedTimeout(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	stalled := make(chan int)
+	merged := ch.MergeSortedTimeoutCtx(ctx, lessInt, time.Second, stalled, ready(1, 2, 3))
+
+	// the merge moves on without the stalled source
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, <-merged)
+	assert.Equal(t, 2, <-merged)
+	assert.Equal(t, 3, <-merged)
+
+	// the late element of the stalled source is emitted as soon as it arrives
+	stalled <- 0
+	assert.Equal(t, 0, <-merged)
+	close(stalled)
+	assert.Empty(t, ch.ToSlice(merged))
+}
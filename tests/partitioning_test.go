@@ -1,6 +1,7 @@
 package chanstreamingtests_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -84,3 +85,36 @@ func TestPartition(t *testing.T) {
 	assert.Equal(t, evenExpected, evenResults)
 	assert.Equal(t, oddExpected, oddResults)
 }
+
+func TestPartitionCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	source := ch.FromSlice([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+	partitions := ch.PartitionCtx(ctx, 2, func(i int) int {
+		return i % 2
+	})(source)
+
+	// read from a single partition only, leaving the other one abandoned
+	assert.Equal(t, 0, <-partitions[0])
+	cancel()
+
+	for _, partition := range partitions {
+		for range partition {
+		}
+	}
+}
+
+func TestMergeCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	source1 := make(chan int)
+	source2 := make(chan int)
+	out := ch.MergeCtx(ctx, []<-chan int{source1, source2})
+
+	source1 <- 1
+	assert.Equal(t, 1, <-out)
+	cancel()
+
+	// the output closes even though neither source is closed
+	for range out {
+	}
+}
@@ -0,0 +1,126 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipe3(t *testing.T) {
+	stage := ch.Pipe3(
+		ch.Mapped(func(i int) int { return i * 2 }),
+		ch.Filter(func(i int) bool { return i > 4 }),
+		ch.Mapped(strconv.Itoa),
+	)
+
+	result := ch.ToSlice(stage(ch.FromSlice([]int{1, 2, 3, 4})))
+	assert.Equal(t, []string{"6", "8"}, result)
+}
+
+func TestPipelineRun(t *testing.T) {
+	pipeline := ch.NewPipeline(ch.FromSlice([]int{1, 2, 3, 4, 5})).
+		Via("double", ch.Mapped(func(i int) int { return i * 2 })).
+		Then(ch.Mapped(strconv.Itoa))
+	flow := ch.To(pipeline, "collect", ch.ToSlice[string])
+
+	result, err := flow.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "4", "6", "8", "10"}, result)
+}
+
+func TestPipelineTypeMismatch(t *testing.T) {
+	pipeline := ch.NewPipeline(ch.FromSlice([]int{1, 2, 3})).
+		Via("stringify", ch.Mapped(strconv.Itoa)).
+		Via("double", ch.Mapped(func(i int) int { return i * 2 })).
+		Via("never added", ch.Mapped(strconv.Itoa))
+
+	_, err := ch.To(pipeline, "collect", ch.ToSlice[string]).Run(context.Background())
+	var pipelineErr *ch.PipelineError
+	assert.True(t, errors.As(err, &pipelineErr))
+	assert.Equal(t, "double", pipelineErr.Stage)
+}
+
+func TestPipelineCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	source := make(chan int)
+	pipeline := ch.NewPipeline(source).
+		Via("cancel on third", ch.Apply(func(i int) {
+			if i == 3 {
+				cancel()
+			}
+		}))
+	go func() {
+		// the source is never closed, the pipeline terminates on cancellation only
+		for i := 1; ; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	result, err := ch.To(pipeline, "collect", ch.ToSlice[int]).Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []int{1, 2, 3}, result[:3])
+}
+
+func TestPipelineReportsStagePanics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := make(chan int)
+	go func() {
+		// the source is never closed, the first fatal error cuts it off
+		for i := 1; ; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	pipeline := ch.NewPipeline(source).
+		Via("parse", ch.MapSafe(func(i int) int {
+			if i == 3 {
+				panic("three")
+			}
+			return i
+		}, 1)).
+		Via("carry", ch.Mapped(func(r ch.Result[int]) ch.Result[int] { return r }))
+
+	result, err := ch.To(pipeline, "collect", ch.ToSlice[ch.Result[int]]).Run(ctx)
+	var pipelineErr *ch.PipelineError
+	assert.True(t, errors.As(err, &pipelineErr))
+	assert.Equal(t, "parse", pipelineErr.Stage)
+	assert.ErrorContains(t, err, "three")
+	assert.Equal(t, 1, result[0].Data)
+	assert.Equal(t, 2, result[1].Data)
+	assert.Error(t, result[2].Error)
+}
+
+func TestPipelineSinkReturningEarly(t *testing.T) {
+	before := runtime.NumGoroutine()
+	// a buffered source without a producer, the Pipeline leaves the rest of the source to its producer
+	source := make(chan int, 5)
+	for i := 1; i <= 5; i++ {
+		source <- i
+	}
+	close(source)
+	pipeline := ch.NewPipeline(source).
+		Via("wrap", ch.Mapped(func(i int) ch.Result[int] { return ch.Result[int]{Data: i} })).
+		Via("carry", ch.Mapped(func(r ch.Result[int]) ch.Result[int] { return r }))
+	first := func(in <-chan ch.Result[int]) int {
+		return (<-in).Data
+	}
+
+	result, err := ch.To(pipeline, "first", first).Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result)
+	// the rest of the stages are drained once the sink returns
+	assertNoLeakedGoroutines(t, before)
+}
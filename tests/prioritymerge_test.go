@@ -0,0 +1,46 @@
+package chanstreamingtests_test
+
+import (
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// ready returns a closed channel with all the elements ready to be read.
+func ready[T any](elements ...T) <-chan T {
+	out := make(chan T, len(elements))
+	for _, x := range elements {
+		out <- x
+	}
+	close(out)
+	return out
+}
+
+func TestMergePrioritized(t *testing.T) {
+	bulk := ready("b1", "b2", "b3")
+	control := ready("c1", "c2")
+	merged := ch.MergePrioritized([]<-chan string{control, bulk})
+	assert.Equal(t, []string{"c1", "c2", "b1", "b2", "b3"}, ch.ToSlice(merged))
+}
+
+func TestMergePrioritizedWaits(t *testing.T) {
+	control := make(chan string)
+	bulk := make(chan string)
+	merged := ch.MergePrioritized([]<-chan string{control, bulk})
+
+	bulk <- "b1"
+	assert.Equal(t, "b1", <-merged)
+	control <- "c1"
+	assert.Equal(t, "c1", <-merged)
+	close(control)
+	close(bulk)
+	assert.Empty(t, ch.ToSlice(merged))
+}
+
+func TestMergeWeighted(t *testing.T) {
+	a := ready("a1", "a2", "a3", "a4")
+	b := ready("b1", "b2", "b3", "b4")
+	merged := ch.MergeWeighted([]<-chan string{a, b}, []int{2, 1})
+	assert.Equal(t, []string{"a1", "a2", "b1", "a3", "a4", "b2", "b3", "b4"}, ch.ToSlice(merged))
+}
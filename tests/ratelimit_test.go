@@ -0,0 +1,57 @@
+package chanstreamingtests_test
+
+import (
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitTokenBucketBurst(t *testing.T) {
+	limiter := ch.NewTokenBucket(1, 20*time.Millisecond, 3)
+
+	start := time.Now()
+	result := ch.ToSlice(ch.RateLimit[int](limiter)(ch.FromSlice([]int{1, 2, 3, 4, 5})))
+	elapsed := time.Since(start)
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, result)
+	// 3 items pass as a burst, the other 2 wait for refills
+	assert.GreaterOrEqual(t, elapsed, 35*time.Millisecond)
+}
+
+func TestRateLimitSharedLeakyBucket(t *testing.T) {
+	limiter := ch.NewLeakyBucket(1, 10*time.Millisecond)
+
+	start := time.Now()
+	first := ch.RateLimit[int](limiter)(ch.FromSlice([]int{1, 2, 3, 4, 5}))
+	second := ch.RateLimit[int](limiter)(ch.FromSlice([]int{6, 7, 8, 9, 10}))
+	result := ch.ToSlice(ch.Merged(first, second))
+	elapsed := time.Since(start)
+
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, result)
+	// both pipelines share 1 permit per 10 milliseconds
+	assert.GreaterOrEqual(t, elapsed, 90*time.Millisecond)
+}
+
+func TestRateLimitWeightedSlidingWindowLog(t *testing.T) {
+	limiter := ch.NewSlidingWindowLog(4, 50*time.Millisecond)
+
+	start := time.Now()
+	result := ch.ToSlice(ch.RateLimitWeighted(limiter, func(x int) int { return x })(ch.FromSlice([]int{2, 2, 2, 1})))
+	elapsed := time.Since(start)
+
+	assert.Equal(t, []int{2, 2, 2, 1}, result)
+	// the third item exceeds the 4 permits per window
+	assert.GreaterOrEqual(t, elapsed, 50*time.Millisecond)
+}
+
+func TestRateLimitersRejectNonPositiveRates(t *testing.T) {
+	assert.Panics(t, func() { ch.NewTokenBucket(0, time.Second, 1) })
+	assert.Panics(t, func() { ch.NewTokenBucket(1, 0, 1) })
+	assert.Panics(t, func() { ch.NewLeakyBucket(-1, time.Second) })
+	assert.Panics(t, func() { ch.NewLeakyBucket(1, -time.Second) })
+	assert.Panics(t, func() { ch.NewSlidingWindowLog(0, time.Second) })
+	assert.Panics(t, func() { ch.NewSlidingWindowLog(1, 0) })
+	assert.Panics(t, func() { ch.Throttle[int](0) })
+}
@@ -1,6 +1,7 @@
 package chanstreamingtests_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -46,3 +47,53 @@ func TestMapSafe(t *testing.T) {
 	expected := []int{1, 3, 5, 7, 9}
 	assert.Equal(t, expected, result)
 }
+
+func TestNewAsyncResultCtx(t *testing.T) {
+	assert.Equal(t, ch.NewResult(1), <-ch.NewAsyncResultCtx(context.Background(), func(ctx context.Context) int { return 1 }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gate := make(chan struct{})
+	defer close(gate)
+	hung := ch.NewAsyncResultCtx(ctx, func(ctx context.Context) int {
+		<-gate
+		return 1
+	})
+	// the result does not wait for the hung call
+	cancel()
+	assert.ErrorIs(t, (<-hung).Error, context.Canceled)
+}
+
+func TestMapSafeTimeout(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	gate := make(chan struct{})
+	type straggler struct {
+		x    int
+		late ch.Result[int]
+	}
+	stragglers := make(chan straggler, 1)
+	fn := func(ctx context.Context, x int) int {
+		if x == 3 {
+			// ignores the context.Context
+			<-gate
+		}
+		return x * 10
+	}
+	source := make(chan int)
+	out := ch.MapSafeTimeoutCtx(ctx, fn, time.Second, 2, func(x int, late ch.Result[int]) {
+		stragglers <- straggler{x, late}
+	})(source)
+
+	source <- 3
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.ErrorIs(t, (<-out).Error, context.DeadlineExceeded)
+
+	// the abandoned call does not hold back the next elements
+	source <- 4
+	close(source)
+	assert.Equal(t, []ch.Result[int]{ch.NewResult(40)}, ch.ToSlice(out))
+
+	close(gate)
+	assert.Equal(t, straggler{3, ch.NewResult(30)}, <-stragglers)
+}
@@ -0,0 +1,94 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedCall returns a function waiting for the gate of its element before returning it,
+// the elements without a gate return right away.
+func gatedCall(gates map[int]chan struct{}) func(x int) (int, error) {
+	return func(x int) (int, error) {
+		if gate, ok := gates[x]; ok {
+			<-gate
+		}
+		return x, nil
+	}
+}
+
+func TestMapOrderedBounded(t *testing.T) {
+	gates := map[int]chan struct{}{1: make(chan struct{})}
+	completed := make(chan int, 3)
+	fn := func(x int) (int, error) {
+		defer func() { completed <- x }()
+		return gatedCall(gates)(x)
+	}
+	out := ch.MapOrderedBounded(fn, 3, 2, 0)(ch.FromSlice([]int{1, 2, 3}))
+
+	// the calls after the slow head complete while it is running
+	assert.ElementsMatch(t, []int{2, 3}, []int{<-completed, <-completed})
+	close(gates[1])
+	assert.Equal(t, []ch.Result[int]{ch.NewResult(1), ch.NewResult(2), ch.NewResult(3)}, ch.ToSlice(out))
+}
+
+func TestMapOrderedBoundedErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	fn := func(x int) (int, error) {
+		if x == 2 {
+			return 0, errBoom
+		}
+		if x == 3 {
+			panic("three")
+		}
+		return x, nil
+	}
+	result := ch.ToSlice(ch.MapOrderedBounded(fn, 2, 1, 0)(ch.FromSlice([]int{1, 2, 3, 4})))
+	assert.Len(t, result, 4)
+	assert.Equal(t, ch.NewResult(1), result[0])
+	assert.ErrorIs(t, result[1].Error, errBoom)
+	assert.EqualError(t, result[2].Error, "three")
+	assert.Equal(t, ch.NewResult(4), result[3])
+}
+
+func TestMapOrderedBoundedTimeout(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	gates := map[int]chan struct{}{1: make(chan struct{})}
+	defer close(gates[1])
+	completed := make(chan int, 2)
+	fn := func(x int) (int, error) {
+		defer func() { completed <- x }()
+		return gatedCall(gates)(x)
+	}
+	out := ch.MapOrderedBoundedCtx(ctx, fn, 3, 2, time.Second)(ch.FromSlice([]int{1, 2, 3}))
+
+	// the stuck head is given up on its deadline, unblocking the rest of the line
+	<-completed
+	<-completed
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	head := <-out
+	assert.ErrorIs(t, head.Error, ch.ErrHeadOfLineTimeout)
+	assert.Equal(t, []ch.Result[int]{ch.NewResult(2), ch.NewResult(3)}, ch.ToSlice(out))
+}
+
+func TestMapOrderedBoundedIdleSource(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan int)
+	out := ch.MapOrderedBoundedCtx(ctx, gatedCall(nil), 2, 2, time.Second)(source)
+
+	source <- 1
+	assert.Equal(t, ch.NewResult(1), <-out)
+	// the source stays idle past the timeout of the element already emitted
+	clock.Advance(2 * time.Second)
+	source <- 2
+	assert.Equal(t, ch.NewResult(2), <-out)
+	close(source)
+	assert.Empty(t, ch.ToSlice(out))
+}
@@ -0,0 +1,109 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyCalls fails the first `failures` calls for each element, panicking on odd ones.
+type flakyCalls struct {
+	mu       sync.Mutex
+	calls    map[int]int
+	failures int
+}
+
+func (f *flakyCalls) call(x int) (int, error) {
+	f.mu.Lock()
+	f.calls[x]++
+	attempt := f.calls[x]
+	f.mu.Unlock()
+	if attempt <= f.failures {
+		if x%2 == 1 {
+			panic("flaky panic")
+		}
+		return 0, errors.New("flaky error")
+	}
+	return x * 10, nil
+}
+
+func TestMapRetry(t *testing.T) {
+	flaky := &flakyCalls{calls: map[int]int{}, failures: 2}
+	policy := ch.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     ch.ExponentialBackoff,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	}
+
+	results := ch.ToSlice(ch.MapRetry(flaky.call, policy, 4)(ch.FromSlice([]int{1, 2, 3})))
+
+	assert.Equal(t, 3, len(results))
+	for i, result := range results {
+		assert.NoError(t, result.Error)
+		assert.Equal(t, (i+1)*10, result.Data)
+		assert.Equal(t, 3, result.Attempts)
+		assert.Equal(t, 2, len(result.Errors))
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	permanent := errors.New("permanent")
+	policy := ch.RetryPolicy{MaxAttempts: 5, Backoff: ch.DecorrelatedJitterBackoff, BaseDelay: time.Millisecond}
+	policy = policy.WithRetryable(func(err error) bool { return !errors.Is(err, permanent) })
+
+	result := ch.Retry(func() (int, error) { return 0, permanent }, policy)
+	assert.ErrorIs(t, result.Error, permanent)
+	assert.Equal(t, 1, result.Attempts)
+
+	exhausted := <-ch.NewAsyncRetry(func() (int, error) { return 0, errors.New("transient") }, policy)
+	assert.EqualError(t, exhausted.Error, "transient")
+	assert.Equal(t, 5, exhausted.Attempts)
+}
+
+func TestRetryBudget(t *testing.T) {
+	policy := ch.RetryPolicy{MaxAttempts: 10}
+	policy = policy.WithBudget(ch.NewRetryBudget(0, 3))
+	failing := func() (int, error) { return 0, errors.New("failing") }
+
+	// the budget allows 3 retries in total across the calls
+	assert.Equal(t, 4, ch.Retry(failing, policy).Attempts)
+	assert.Equal(t, 1, ch.Retry(failing, policy).Attempts)
+}
+
+// delayRecordingClock records the delays slept by the retries, firing their timers at once.
+type delayRecordingClock struct {
+	*ch.ManualClock
+	delays []time.Duration
+}
+
+func (c *delayRecordingClock) NewTimer(d time.Duration) ch.Timer {
+	c.delays = append(c.delays, d)
+	return c.ManualClock.NewTimer(0)
+}
+
+func TestRetryBackoffSaturates(t *testing.T) {
+	failing := func() (int, error) { return 0, errors.New("failing") }
+	for _, backoff := range []ch.BackoffStrategy{ch.ExponentialBackoff, ch.DecorrelatedJitterBackoff} {
+		clock := &delayRecordingClock{ManualClock: ch.NewManualClock(time.Unix(0, 0))}
+		ctx := ch.ContextWithClock(context.Background(), clock)
+		policy := ch.RetryPolicy{MaxAttempts: 100, Backoff: backoff, BaseDelay: time.Hour}
+
+		result := ch.RetryCtx(ctx, failing, policy)
+		assert.Equal(t, 100, result.Attempts)
+		assert.Equal(t, 99, len(clock.delays))
+		for _, delay := range clock.delays {
+			assert.GreaterOrEqual(t, delay, time.Hour)
+		}
+		if backoff == ch.ExponentialBackoff {
+			assert.IsNonDecreasing(t, clock.delays)
+			assert.Equal(t, time.Duration(math.MaxInt64), clock.delays[98])
+		}
+	}
+}
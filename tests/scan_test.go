@@ -1,6 +1,7 @@
 package chanstreamingtests_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -38,33 +39,28 @@ func TestFold(t *testing.T) {
 }
 
 func TestWithSlidingWindowTimed(t *testing.T) {
-	source := make(chan int, 10)
-	go func() {
-		defer close(source)
-		for i := 1; i <= 10; i++ {
-			source <- i
-		}
-	}()
-
-	slidingWindow := ch.WithSlidingWindowTimed[int](time.Millisecond * 100)(source)
-	hardCopies := ch.Mapped[[]int, []int](func(x []int) []int {
-		result := make([]int, len(x))
-		copy(result, x)
-		return result
-	})(slidingWindow)
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan int)
+	config := ch.WindowConfig{}
+	slidingWindow := ch.WithSlidingWindowCtx[int](ctx, config.WithDuration(time.Millisecond*100))(source)
 
-	results := ch.ToSlice(hardCopies)
-	if len(results) != 10 { // including empty starting window
-		t.Error("Expected 10 results, got", len(results))
+	results := [][]int{}
+	for i := 1; i <= 10; i++ {
+		// an element every 30 milliseconds, the window keeps the last 100 milliseconds of them
+		source <- i
+		results = append(results, <-slidingWindow)
+		clock.Advance(time.Millisecond * 30)
 	}
+	close(source)
 
-	for i, r := range results {
-		if len(r) <= 12 && len(r) >= 9 && i > 10 {
-			t.Error("Expected 9 to 12 results in window", i, "got", len(r))
-		} else if len(r) <= i+1 && i > 10 {
-			t.Error("Expected up to", i+1, "results in window", i, "got", len(r))
-		}
+	expected := [][]int{
+		{1}, {1, 2}, {1, 2, 3}, {1, 2, 3, 4},
+		{2, 3, 4, 5}, {3, 4, 5, 6}, {4, 5, 6, 7}, {5, 6, 7, 8}, {6, 7, 8, 9}, {7, 8, 9, 10},
 	}
+	assert.Equal(t, expected, results)
+	_, ok := <-slidingWindow
+	assert.False(t, ok)
 }
 
 func TestWithSlidingWindowCount(t *testing.T) {
@@ -0,0 +1,116 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// firstLetter keys the test elements by their first letter.
+func firstLetter(x string) string {
+	return x[:1]
+}
+
+// collectSessions collects the sessions in the background, skipping the sessions of the "z" key used to sync with the operator:
+// once a "z" element is received, the elements sent before it are guaranteed to be stamped.
+func collectSessions(sessions <-chan ch.KeyedWindow[string, string]) <-chan []ch.KeyedWindow[string, string] {
+	collected := make(chan []ch.KeyedWindow[string, string], 1)
+	go func() {
+		result := []ch.KeyedWindow[string, string]{}
+		for s := range sessions {
+			if s.Key != "z" {
+				result = append(result, s)
+			}
+		}
+		collected <- result
+	}()
+	return collected
+}
+
+func TestSessionWindow(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan string)
+	collected := collectSessions(ch.SessionWindowCtx[string, string](ctx, firstLetter, 5*time.Second, ch.SessionConfig{})(source))
+
+	source <- "a1"
+	source <- "b1"
+	source <- "z"
+	clock.Advance(3 * time.Second)
+	source <- "a2"
+	source <- "z"
+	clock.Advance(3 * time.Second)
+	clock.Advance(3 * time.Second)
+	close(source)
+
+	result := <-collected
+	assert.Len(t, result, 2)
+	assert.Equal(t, "b", result[0].Key)
+	assert.Equal(t, []string{"b1"}, result[0].Elements)
+	assert.Equal(t, time.Unix(0, 0), result[0].Start)
+	assert.Equal(t, time.Unix(5, 0), result[0].End)
+	assert.Equal(t, "a", result[1].Key)
+	assert.Equal(t, []string{"a1", "a2"}, result[1].Elements)
+	assert.Equal(t, time.Unix(8, 0), result[1].End)
+}
+
+func TestSessionWindowMaxLength(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan string)
+	config := ch.SessionConfig{}
+	config = config.WithMaxLength(4 * time.Second)
+	collected := collectSessions(ch.SessionWindowCtx[string, string](ctx, firstLetter, 5*time.Second, config)(source))
+
+	source <- "a1"
+	source <- "z"
+	clock.Advance(3 * time.Second)
+	source <- "a2"
+	source <- "z"
+	clock.Advance(3 * time.Second)
+	source <- "a3"
+	close(source)
+
+	result := <-collected
+	assert.Len(t, result, 2)
+	assert.Equal(t, []string{"a1", "a2"}, result[0].Elements)
+	assert.Equal(t, time.Unix(4, 0), result[0].End)
+	assert.Equal(t, []string{"a3"}, result[1].Elements)
+	assert.Equal(t, time.Unix(6, 0), result[1].Start)
+}
+
+func TestSessionWindowMaxSessions(t *testing.T) {
+	config := ch.SessionConfig{}
+	config = config.WithMaxSessions(2)
+
+	// the new sessions beyond the limit are dropped
+	dropTail := config.WithOverflowStrategy(ch.DropTail)
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	result := ch.ToSlice(ch.SessionWindowCtx[string, string](ctx, firstLetter, 5*time.Second, dropTail)(ch.FromSlice([]string{"a1", "b1", "c1", "a2"})))
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	assert.Len(t, result, 2)
+	assert.Equal(t, []string{"a1", "a2"}, result[0].Elements)
+	assert.Equal(t, []string{"b1"}, result[1].Elements)
+
+	// the oldest session is closed to make room for the new one
+	dropHead := config.WithOverflowStrategy(ch.DropHead)
+	source := make(chan string)
+	collected := collectSessions(ch.SessionWindowCtx[string, string](ctx, firstLetter, 5*time.Second, dropHead)(source))
+	source <- "a1"
+	source <- "a2"
+	clock.Advance(time.Second)
+	source <- "b1"
+	source <- "b2"
+	source <- "c1"
+	close(source)
+
+	result = <-collected
+	assert.Len(t, result, 3)
+	assert.Equal(t, "a", result[0].Key)
+	assert.Equal(t, []string{"a1", "a2"}, result[0].Elements)
+}
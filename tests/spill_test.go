@@ -0,0 +1,104 @@
+package chanstreamingtests_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func segmentFiles(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	return len(entries)
+}
+
+func TestSpillBuffer(t *testing.T) {
+	dir := t.TempDir()
+	config := ch.SpillConfig{Dir: dir, MemorySize: 2, SegmentSize: 8}
+	source := make(chan int)
+	out := ch.SpillBuffer[int](ch.JSONCodec[int]{}, config)(source)
+
+	// the producer is never blocked, the elements beyond the memory go to the segment files
+	for i := 1; i <= 20; i++ {
+		source <- i
+	}
+	spilled := segmentFiles(t, dir)
+	assert.Greater(t, spilled, 1)
+
+	for i := 1; i <= 10; i++ {
+		assert.Equal(t, ch.NewResult(i), <-out)
+	}
+	// the segments read through are removed
+	assert.Less(t, segmentFiles(t, dir), spilled)
+
+	source <- 21
+	close(source)
+	var rest []int
+	for r := range out {
+		assert.NoError(t, r.Error)
+		rest = append(rest, r.Data)
+	}
+	assert.Equal(t, []int{11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21}, rest)
+	assert.Equal(t, 0, segmentFiles(t, dir))
+}
+
+// oddCodec fails to decode the odd numbers
+type oddCodec struct {
+	ch.JSONCodec[int]
+}
+
+var errOdd = errors.New("odd")
+
+func (c oddCodec) Decode(data []byte) (int, error) {
+	x, err := c.JSONCodec.Decode(data)
+	if err == nil && x%2 == 1 {
+		return 0, errOdd
+	}
+	return x, err
+}
+
+func TestSpillBufferDecodeError(t *testing.T) {
+	dir := t.TempDir()
+	source := make(chan int)
+	out := ch.SpillBuffer[int](oddCodec{}, ch.SpillConfig{Dir: dir, MemorySize: 1})(source)
+	for i := 1; i <= 4; i++ {
+		source <- i
+	}
+	close(source)
+
+	result := ch.ToSlice(out)
+	assert.Len(t, result, 4)
+	// the first element is kept in memory, never going through the codec
+	assert.Equal(t, ch.NewResult(1), result[0])
+	assert.Equal(t, ch.NewResult(2), result[1])
+	assert.ErrorIs(t, result[2].Error, errOdd)
+	assert.Equal(t, ch.NewResult(4), result[3])
+	assert.Equal(t, 0, segmentFiles(t, dir))
+}
+
+func TestSpillBufferWriteError(t *testing.T) {
+	config := ch.SpillConfig{Dir: filepath.Join(t.TempDir(), "missing"), MemorySize: 2}
+	source := make(chan int)
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		defer close(source)
+		for i := range 10 {
+			source <- i
+		}
+	}()
+
+	out := ch.SpillBuffer[int](ch.JSONCodec[int]{}, config)(source)
+	// the producer is not left blocked once the spilling fails, even with nobody reading the output
+	<-produced
+	results := ch.ToSlice(out)
+
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, 0, results[0].Data)
+	assert.Equal(t, 1, results[1].Data)
+	assert.Error(t, results[2].Error)
+}
@@ -1,6 +1,7 @@
 package chanstreamingtests_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -9,6 +10,8 @@ import (
 )
 
 func TestThrottle(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
 	source := make(chan int)
 	go func() {
 		defer close(source)
@@ -17,33 +20,65 @@ func TestThrottle(t *testing.T) {
 		}
 	}()
 
-	throttle := ch.Throttle[int](10 * time.Millisecond)
+	throttle := ch.ThrottleCtx[int](ctx, 10*time.Millisecond)
 	out := throttle(source)
 
-	start := time.Now()
-	result := ch.ToSlice(out)
-	elapsed := time.Since(start)
+	start := clock.Now()
+	result := []int{}
+	for range 5 {
+		// each element waits for its own permit
+		clock.BlockUntil(1)
+		clock.Advance(10 * time.Millisecond)
+		result = append(result, <-out)
+	}
+	_, ok := <-out
+	elapsed := clock.Now().Sub(start)
 
+	assert.False(t, ok)
 	assert.Equal(t, result, []int{0, 1, 2, 3, 4})
-	assert.GreaterOrEqual(t, elapsed, 50*time.Millisecond) // 5 elements with 10 milliseconds delay before emitting each of it
+	assert.Equal(t, elapsed, 50*time.Millisecond) // 5 elements with 10 milliseconds delay before emitting each of it
 }
 
 func TestJitter(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
 	source := make(chan int)
-	go func() {
-		defer close(source)
-		for i := range 5 {
-			source <- i
-		}
-	}()
 
-	throttle := ch.Jitter[int](10 * time.Millisecond)
+	jitter := 10 * time.Millisecond
+	throttle := ch.JitterCtx[int](ctx, jitter)
 	out := throttle(source)
 
+	// next reads an element, the elements with a non-positive delay are passed through at once,
+	// the others wait on a timer of the ManualClock
+	next := func() int {
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			select {
+			case x := <-out:
+				return x
+			default:
+			}
+			if clock.Waiters() > 0 {
+				// the max delay
+				clock.Advance(jitter / 2)
+				return <-out
+			}
+		}
+		t.Fatal("the element is neither passed through nor delayed")
+		return 0
+	}
+
 	// TODO: it's better to test the histogram of delays
-	start := time.Now()
-	result := ch.ToSlice(out)
-	elapsed := time.Since(start)
+	start := clock.Now()
+	result := []int{}
+	for i := range 5 {
+		source <- i
+		result = append(result, next())
+	}
+	close(source)
+	_, ok := <-out
+	elapsed := clock.Now().Sub(start)
+
+	assert.False(t, ok)
 	assert.Equal(t, []int{0, 1, 2, 3, 4}, result)
-	assert.LessOrEqual(t, elapsed, 50*time.Millisecond)
+	assert.LessOrEqual(t, elapsed, 5*jitter/2)
 }
@@ -0,0 +1,113 @@
+package chanstreamingtests_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// failOnEven is a fallible function that fails on even numbers.
+func failOnEven(i int) (int, error) {
+	if i%2 == 0 {
+		return 0, fmt.Errorf("even %d", i)
+	}
+	return i * 10, nil
+}
+
+func TestTryMapPolicies(t *testing.T) {
+	theSlice := []int{1, 2, 3, 4, 5}
+
+	shortCircuit := ch.ToSlice(ch.TryMap(failOnEven, ch.ShortCircuit)(ch.AsResults(ch.FromSlice(theSlice))))
+	assert.Equal(t, []ch.Result[int]{{Data: 10}, {Error: errors.New("even 2")}}, shortCircuit)
+
+	collected := ch.ToSlice(ch.TryMap(failOnEven, ch.CollectErrors)(ch.AsResults(ch.FromSlice(theSlice))))
+	assert.Equal(t, 5, len(collected))
+	assert.Equal(t, 2, len(ch.ToSlice(ch.Filter(ch.IsError[int])(ch.FromSlice(collected)))))
+
+	skipped := ch.ToSlice(ch.Muted(ch.TryMap(failOnEven, ch.SkipErrors)(ch.AsResults(ch.FromSlice(theSlice)))))
+	assert.Equal(t, []int{10, 30, 50}, skipped)
+}
+
+func TestTryFilter(t *testing.T) {
+	source := ch.AsResults(ch.FromSlice([]int{1, 2, 3, 4, 5, 6}))
+	filtered := ch.TryFilter(func(i int) (bool, error) {
+		if i == 5 {
+			return false, errors.New("five")
+		}
+		return i%2 == 0, nil
+	}, ch.SkipErrors)(source)
+
+	assert.Equal(t, []int{2, 4, 6}, ch.ToSlice(ch.Muted(filtered)))
+}
+
+func TestTryFlatMapShortCircuit(t *testing.T) {
+	source := ch.AsResults(ch.FromSlice([]int{1, 2, 3}))
+	flattened := ch.TryFlatMap(func(i int) <-chan ch.Result[int] {
+		return ch.FromSlice([]ch.Result[int]{ch.NewResult(i), ch.NewError[int](fmt.Errorf("after %d", i))})
+	}, ch.ShortCircuit)(source)
+
+	result := ch.ToSlice(flattened)
+	assert.Equal(t, []ch.Result[int]{{Data: 1}, {Error: errors.New("after 1")}}, result)
+}
+
+func TestTryFoldCollectErrors(t *testing.T) {
+	source := ch.AsResults(ch.FromSlice([]int{1, 2, 3, 4, 5}))
+	folded := ch.TryFold(func(acc int, i int) (int, error) {
+		if i%2 == 0 {
+			return acc, fmt.Errorf("even %d", i)
+		}
+		return acc + i, nil
+	}, 0, ch.CollectErrors)(source)
+
+	result := <-folded
+	assert.Equal(t, 9, result.Data)
+	assert.EqualError(t, result.Error, "even 2\neven 4")
+}
+
+func TestTryOperatorsRecoverPanics(t *testing.T) {
+	theSlice := []int{1, 2, 3}
+
+	mapped := ch.ToSlice(ch.TryMap(func(i int) (int, error) {
+		if i == 2 {
+			panic("two")
+		}
+		return i * 10, nil
+	}, ch.CollectErrors)(ch.AsResults(ch.FromSlice(theSlice))))
+	assert.Equal(t, []ch.Result[int]{{Data: 10}, {Error: errors.New("two")}, {Data: 30}}, mapped)
+
+	filtered := ch.ToSlice(ch.TryFilter(func(i int) (bool, error) {
+		if i == 2 {
+			panic("two")
+		}
+		return true, nil
+	}, ch.ShortCircuit)(ch.AsResults(ch.FromSlice(theSlice))))
+	assert.Equal(t, []ch.Result[int]{{Data: 1}, {Data: 2, Error: errors.New("two")}}, filtered)
+
+	flattened := ch.ToSlice(ch.Muted(ch.TryFlatMap(func(i int) <-chan ch.Result[int] {
+		if i == 2 {
+			panic("two")
+		}
+		return ch.AsResults(ch.FromSlice([]int{i}))
+	}, ch.SkipErrors)(ch.AsResults(ch.FromSlice(theSlice)))))
+	assert.Equal(t, []int{1, 3}, flattened)
+
+	folded := <-ch.TryFold(func(acc int, i int) (int, error) {
+		if i == 2 {
+			panic("two")
+		}
+		return acc + i, nil
+	}, 0, ch.CollectErrors)(ch.AsResults(ch.FromSlice(theSlice)))
+	assert.Equal(t, 4, folded.Data)
+	assert.EqualError(t, folded.Error, "two")
+}
+
+func TestMapError(t *testing.T) {
+	wrapped := ch.MapError[int](func(err error) error {
+		return fmt.Errorf("wrapped: %w", err)
+	})(ch.NewError[int](errors.New("boom")))
+
+	assert.EqualError(t, wrapped.Error, "wrapped: boom")
+}
@@ -0,0 +1,141 @@
+package chanstreamingtests_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+// The processing-time window tests send a 0 after the elements of each window: once it is received,
+// the elements before it are guaranteed to be stamped, while the 0 itself may land in either window.
+
+func TestTumblingWindow(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan int)
+	windows := ch.TumblingWindowCtx[int](ctx, 10*time.Second)(source)
+	collected := make(chan []ch.Window[int])
+	go func() {
+		collected <- ch.ToSlice(windows)
+	}()
+
+	source <- 1
+	source <- 2
+	source <- 0
+	clock.Advance(10 * time.Second)
+	source <- 3
+	source <- 0
+	close(source)
+
+	result := <-collected
+	assert.Len(t, result, 2)
+	assert.Equal(t, []int{1, 2}, ch.ToSlice(ch.Filter(func(x int) bool { return x != 0 })(ch.FromSlice(result[0].Elements))))
+	assert.Equal(t, []int{3}, ch.ToSlice(ch.Filter(func(x int) bool { return x != 0 })(ch.FromSlice(result[1].Elements))))
+	assert.Equal(t, time.Unix(0, 0), result[0].Start)
+	assert.Equal(t, time.Unix(10, 0), result[0].End)
+	assert.Equal(t, time.Unix(20, 0), result[1].End)
+}
+
+func TestHoppingWindowAggregate(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan int)
+	windows := ch.HoppingWindowAggregateCtx[int, int](ctx, 10*time.Second, 5*time.Second, ch.NewSumAggregator[int])(source)
+	collected := make(chan []ch.WindowAggregate[int])
+	go func() {
+		collected <- ch.ToSlice(windows)
+	}()
+
+	source <- 1
+	source <- 0
+	clock.Advance(5 * time.Second)
+	source <- 2
+	source <- 0
+	clock.Advance(5 * time.Second)
+	source <- 4
+	source <- 0
+	close(source)
+
+	result := <-collected
+	sums := []int{}
+	starts := []time.Time{}
+	for _, w := range result {
+		sums = append(sums, w.Result)
+		starts = append(starts, w.Start)
+	}
+	// each element stays in two consecutive windows
+	assert.Equal(t, []int{1, 3, 6}, sums)
+	assert.Equal(t, []time.Time{time.Unix(-5, 0), time.Unix(0, 0), time.Unix(5, 0)}, starts)
+}
+
+func TestTumblingWindowSkipsEmptyWindows(t *testing.T) {
+	clock := ch.NewManualClock(time.Unix(0, 0))
+	ctx := ch.ContextWithClock(context.Background(), clock)
+	source := make(chan int)
+	windows := ch.TumblingWindowCtx[int](ctx, time.Second)(source)
+
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Second)
+	close(source)
+	assert.Empty(t, ch.ToSlice(windows))
+}
+
+func TestCountWindow(t *testing.T) {
+	sliding := ch.ToSlice(ch.CountWindow[int](3, 1)(ch.FromSlice([]int{1, 2, 3, 4, 5})))
+	elements := [][]int{}
+	for _, w := range sliding {
+		elements = append(elements, w.Elements)
+	}
+	assert.Equal(t, [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}, elements)
+
+	// elements between the windows are skipped, the last window is shorter
+	sparse := ch.ToSlice(ch.CountWindow[int](2, 3)(ch.FromSlice([]int{1, 2, 3, 4, 5, 6, 7})))
+	elements = [][]int{}
+	for _, w := range sparse {
+		elements = append(elements, w.Elements)
+	}
+	assert.Equal(t, [][]int{{1, 2}, {4, 5}, {7}}, elements)
+}
+
+func TestCountWindowAggregate(t *testing.T) {
+	windows := ch.CountWindowAggregate[int, float64](3, 2, ch.NewAverageAggregator[int])(ch.FromSlice([]int{1, 2, 3, 4, 5, 6}))
+	averages := []float64{}
+	counts := []int{}
+	for w := range windows {
+		averages = append(averages, w.Result)
+		counts = append(counts, w.Count)
+	}
+	assert.Equal(t, []float64{2, 4, 5.5}, averages)
+	assert.Equal(t, []int{3, 3, 2}, counts)
+}
+
+func TestPercentileAggregator(t *testing.T) {
+	aggregator := ch.NewPercentileAggregator[int](0.9)
+	assert.Equal(t, 0, aggregator.Result())
+	for _, x := range []int{5, 1, 9, 3, 7, 2, 8, 4, 10, 6} {
+		aggregator.Add(x)
+	}
+	assert.Equal(t, 9, aggregator.Result())
+	aggregator.Remove(5)
+	aggregator.Remove(1)
+	assert.Equal(t, 10, aggregator.Result())
+	aggregator.Remove(10)
+	assert.Equal(t, 9, aggregator.Result())
+
+	median := ch.NewPercentileAggregator[int](0.5)
+	for _, x := range []int{3, 1, 2} {
+		median.Add(x)
+	}
+	assert.Equal(t, 2, median.Result())
+}
+
+func TestWindowsRejectNonPositiveSizes(t *testing.T) {
+	assert.Panics(t, func() { ch.TumblingWindow[int](0) })
+	assert.Panics(t, func() { ch.HoppingWindow[int](time.Second, 0) })
+	assert.Panics(t, func() { ch.HoppingWindowAggregate[int, int](-time.Second, time.Second, ch.NewSumAggregator[int]) })
+	assert.Panics(t, func() { ch.CountWindow[int](0, 1) })
+	assert.Panics(t, func() { ch.CountWindowAggregate[int, int](2, -1, ch.NewSumAggregator[int]) })
+}
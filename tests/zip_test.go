@@ -0,0 +1,80 @@
+package chanstreamingtests_test
+
+import (
+	"testing"
+
+	ch "github.com/diemenator/go-chanstreaming/pkg/chanstreaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZip(t *testing.T) {
+	shortest := ch.ToSlice(ch.Zip[int, string](ch.ZipShortest)(ch.FromSlice([]int{1, 2, 3}), ch.FromSlice([]string{"a", "b"})))
+	assert.Equal(t, []ch.Pair[int, string]{{Left: 1, Right: "a"}, {Left: 2, Right: "b"}}, shortest)
+
+	longest := ch.ToSlice(ch.Zip[int, string](ch.ZipLongest)(ch.FromSlice([]int{1, 2, 3}), ch.FromSlice([]string{"a", "b"})))
+	assert.Equal(t, []ch.Pair[int, string]{{Left: 1, Right: "a"}, {Left: 2, Right: "b"}, {Left: 3, Right: ""}}, longest)
+}
+
+func TestZipWith(t *testing.T) {
+	add := func(a int, b int) int { return a + b }
+	assert.Equal(t, []int{11}, ch.ToSlice(ch.ZipWith(add, ch.ZipShortest)(ch.FromSlice([]int{1, 2, 3}), ch.FromSlice([]int{10}))))
+	assert.Equal(t, []int{11, 2, 3}, ch.ToSlice(ch.ZipWith(add, ch.ZipLongest)(ch.FromSlice([]int{1, 2, 3}), ch.FromSlice([]int{10}))))
+}
+
+func TestZipLatest(t *testing.T) {
+	left := make(chan int)
+	right := make(chan string)
+	zipped := ch.ZipLatest[int, string]()(left, right)
+
+	left <- 1
+	right <- "a"
+	assert.Equal(t, ch.Pair[int, string]{Left: 1, Right: "a"}, <-zipped)
+	left <- 2
+	assert.Equal(t, ch.Pair[int, string]{Left: 2, Right: "a"}, <-zipped)
+	close(left)
+	right <- "b"
+	assert.Equal(t, ch.Pair[int, string]{Left: 2, Right: "b"}, <-zipped)
+	close(right)
+	assert.Empty(t, ch.ToSlice(zipped))
+}
+
+func TestCombineLatest(t *testing.T) {
+	a := make(chan int)
+	b := make(chan int)
+	c := make(chan int)
+	combined := ch.CombineLatest[int](a, b, c)
+
+	a <- 1
+	b <- 10
+	c <- 100
+	assert.Equal(t, []int{1, 10, 100}, <-combined)
+	a <- 2
+	assert.Equal(t, []int{2, 10, 100}, <-combined)
+	c <- 200
+	assert.Equal(t, []int{2, 10, 200}, <-combined)
+	close(a)
+	close(b)
+	close(c)
+	assert.Empty(t, ch.ToSlice(combined))
+}
+
+func TestWithLatestFrom(t *testing.T) {
+	main := make(chan int)
+	side := make(chan string)
+	enriched := ch.WithLatestFrom[int, string](side)(main)
+
+	// the elements before the first side element are dropped
+	main <- 0
+	side <- "x"
+	main <- 1
+	assert.Equal(t, ch.Pair[int, string]{Left: 1, Right: "x"}, <-enriched)
+	side <- "y"
+	main <- 2
+	assert.Equal(t, ch.Pair[int, string]{Left: 2, Right: "y"}, <-enriched)
+
+	// the side channel is drained once the source completes
+	close(main)
+	assert.Empty(t, ch.ToSlice(enriched))
+	side <- "z"
+	close(side)
+}